@@ -0,0 +1,73 @@
+// Command lc3 runs an LC-3 object file in a terminal.
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/mpatraw/gocurse/curses"
+
+	"github.com/BadgerBadgerBadgerBadger/go-lvc3-vm/lc3/vm"
+)
+
+// cursesIO implements vm.IO on top of gocurse: keypresses are polled
+// non-blockingly via the curses window, and the blocking GETC/IN traps
+// read the raw character back off stdin.
+type cursesIO struct {
+	screen *curses.Window
+	input  *bufio.Reader
+}
+
+func (c *cursesIO) CheckKey() bool {
+	return c.screen.Getch() != 0
+}
+
+func (c *cursesIO) GetChar() uint16 {
+	char, _, err := c.input.ReadRune()
+	if err != nil {
+		panic(err)
+	}
+	return uint16(byte(char))
+}
+
+func (c *cursesIO) Out(ch byte) {
+	fmt.Print(string(ch))
+}
+
+func main() {
+
+	argsWithoutProg := os.Args[1:]
+	fmt.Printf("Input image: %+v\n", argsWithoutProg[0])
+
+	f, err := os.Open(argsWithoutProg[0])
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+
+	io := &cursesIO{input: bufio.NewReader(os.Stdin)}
+	machine := vm.NewDefault(io)
+
+	if err := machine.LoadImageFile(f); err != nil {
+		panic(err)
+	}
+	fmt.Println("Image read.")
+
+	// Initscr() initializes the terminal in curses mode.
+	io.screen, _ = curses.Initscr()
+	// Endwin must be called when done.
+	defer curses.Endwin()
+
+	shutdownCh := make(chan struct{})
+	go func() {
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, os.Interrupt)
+		<-c
+		close(shutdownCh)
+	}()
+	fmt.Println("Interrupt registered.")
+
+	machine.Run(shutdownCh)
+}
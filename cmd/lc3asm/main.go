@@ -0,0 +1,52 @@
+// Command lc3asm assembles an LC-3 source file into a big-endian object
+// file that cmd/lc3 (or any lc3/vm embedder) can load.
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BadgerBadgerBadgerBadger/go-lvc3-vm/lc3/asm"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: lc3asm <source.asm> [output.obj]")
+		os.Exit(1)
+	}
+
+	src, err := os.ReadFile(os.Args[1])
+	checkErr(err)
+
+	program, err := asm.Assemble(string(src))
+	checkErr(err)
+
+	out := os.Args[1]
+	if len(os.Args) >= 3 {
+		out = os.Args[2]
+	} else {
+		out = strings.TrimSuffix(out, filepath.Ext(out)) + ".obj"
+	}
+
+	f, err := os.Create(out)
+	checkErr(err)
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	checkErr(binary.Write(w, binary.BigEndian, program.Origin))
+	checkErr(binary.Write(w, binary.BigEndian, program.Words))
+	checkErr(w.Flush())
+
+	fmt.Printf("Assembled %s -> %s (origin x%04X, %d words)\n", os.Args[1], out, program.Origin, len(program.Words))
+}
+
+func checkErr(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,203 @@
+// Package asm implements a two-pass assembler for LC-3 assembly source,
+// producing the same big-endian object format that lc3/vm loads.
+package asm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Program is the result of assembling a source file: the load address, the
+// encoded words that follow it, and the symbol table (exported so
+// lc3/disasm can annotate a listing with label names).
+type Program struct {
+	Origin  uint16
+	Words   []uint16
+	Symbols map[string]uint16
+}
+
+// Error describes an assembly failure together with the source line it
+// occurred on.
+type Error struct {
+	Line    int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+type statement struct {
+	line     int
+	label    string
+	op       string
+	operands []string
+}
+
+// registerNames maps a register mnemonic to its encoding, for operands that
+// name a register (register is the lookup helper in encode.go).
+var registerNames = map[string]uint16{
+	"R0": 0, "R1": 1, "R2": 2, "R3": 3, "R4": 4, "R5": 5, "R6": 6, "R7": 7,
+}
+
+// Assemble parses LC-3 assembly source and encodes it into a Program.
+func Assemble(src string) (*Program, error) {
+
+	statements, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(statements) == 0 || statements[0].op != ".ORIG" {
+		return nil, &Error{Line: 1, Message: ".ORIG must be the first statement"}
+	}
+
+	origin, err := parseImmediate(statements[0].operands, 0)
+	if err != nil {
+		return nil, &Error{Line: statements[0].line, Message: err.Error()}
+	}
+
+	symbols := map[string]uint16{}
+	lc := origin
+	body := statements[1:]
+
+	for _, s := range body {
+		if s.op == ".END" {
+			break
+		}
+		if s.label != "" {
+			if _, exists := symbols[s.label]; exists {
+				return nil, &Error{Line: s.line, Message: fmt.Sprintf("duplicate label %q", s.label)}
+			}
+			symbols[s.label] = lc
+		}
+		size, err := sizeOf(s)
+		if err != nil {
+			return nil, &Error{Line: s.line, Message: err.Error()}
+		}
+		lc += size
+	}
+
+	words := make([]uint16, 0, lc-origin)
+	lc = origin
+
+	for _, s := range body {
+		if s.op == ".END" {
+			break
+		}
+		encoded, err := encode(s, lc, symbols)
+		if err != nil {
+			return nil, &Error{Line: s.line, Message: err.Error()}
+		}
+		words = append(words, encoded...)
+		lc += uint16(len(encoded))
+	}
+
+	return &Program{Origin: origin, Words: words, Symbols: symbols}, nil
+}
+
+func parse(src string) ([]statement, error) {
+
+	var statements []statement
+
+	for i, raw := range strings.Split(src, "\n") {
+		lineNo := i + 1
+
+		line := raw
+		if idx := strings.IndexByte(line, ';'); idx >= 0 {
+			line = line[:idx]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		fields := tokenize(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		label := ""
+		if !strings.HasPrefix(fields[0], ".") && !isOpcode(fields[0]) {
+			label = fields[0]
+			fields = fields[1:]
+			if len(fields) == 0 {
+				return nil, &Error{Line: lineNo, Message: fmt.Sprintf("label %q with no statement", label)}
+			}
+		}
+
+		op := strings.ToUpper(fields[0])
+		operands := fields[1:]
+
+		statements = append(statements, statement{line: lineNo, label: label, op: op, operands: operands})
+	}
+
+	return statements, nil
+}
+
+// tokenize splits a line into whitespace/comma separated fields, keeping a
+// quoted .STRINGZ argument intact as a single field.
+func tokenize(line string) []string {
+
+	var fields []string
+	var cur strings.Builder
+	inQuotes := false
+
+	flush := func() {
+		if cur.Len() > 0 {
+			fields = append(fields, cur.String())
+			cur.Reset()
+		}
+	}
+
+	for _, r := range line {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			cur.WriteRune(r)
+		case inQuotes:
+			cur.WriteRune(r)
+		case r == ',' || r == ' ' || r == '\t':
+			flush()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+
+	return fields
+}
+
+var opcodes = map[string]bool{
+	"ADD": true, "AND": true, "NOT": true, "LD": true, "LDI": true, "LDR": true,
+	"LEA": true, "ST": true, "STI": true, "STR": true, "JMP": true, "JSR": true,
+	"JSRR": true, "RET": true, "RTI": true, "TRAP": true, "GETC": true, "OUT": true,
+	"PUTS": true, "IN": true, "PUTSP": true, "HALT": true,
+	".ORIG": true, ".FILL": true, ".BLKW": true, ".STRINGZ": true, ".END": true,
+}
+
+func isOpcode(field string) bool {
+	upper := strings.ToUpper(field)
+	return opcodes[upper] || isBranchMnemonic(upper)
+}
+
+// isBranchMnemonic reports whether upper is a BR opcode: "BR" followed by
+// zero or more of the N/Z/P condition letters and nothing else, so labels
+// like BREAK or BROADCAST that merely start with "BR" aren't mistaken for
+// one. Keep this in sync with encodeBr's own, more tolerant parse of the
+// same letters.
+func isBranchMnemonic(upper string) bool {
+	if !strings.HasPrefix(upper, "BR") {
+		return false
+	}
+	flags := upper[2:]
+	if len(flags) > 3 {
+		return false
+	}
+	for _, c := range flags {
+		if c != 'N' && c != 'Z' && c != 'P' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,152 @@
+package asm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/BadgerBadgerBadgerBadger/go-lvc3-vm/lc3/vm"
+)
+
+func TestAssembleResolvesBackwardLabels(t *testing.T) {
+	src := `
+.ORIG x3000
+START   ADD R0, R0, #1
+        BR START
+LOOP    AND R1, R1, #0
+        BR LOOP
+.END
+`
+	p, err := Assemble(src)
+	if err != nil {
+		t.Fatalf("Assemble: %v", err)
+	}
+	if p.Origin != 0x3000 {
+		t.Fatalf("Origin = %#04x, want 0x3000", p.Origin)
+	}
+	if got, want := p.Symbols["START"], uint16(0x3000); got != want {
+		t.Errorf("START = %#04x, want %#04x", got, want)
+	}
+	if got, want := p.Symbols["LOOP"], uint16(0x3002); got != want {
+		t.Errorf("LOOP = %#04x, want %#04x", got, want)
+	}
+
+	// BR START at x3001 branches backward to x3000: offset = target - (pc+1) = -2.
+	var negTwo int16 = -2
+	wantBackward := vm.OpBreak<<12 | 0x7<<9 | uint16(negTwo)&0x1ff
+	if p.Words[1] != wantBackward {
+		t.Errorf("BR START = %#04x, want %#04x", p.Words[1], wantBackward)
+	}
+	// BR LOOP at x3003 branches backward to x3002: offset = -2.
+	if p.Words[3] != wantBackward {
+		t.Errorf("BR LOOP = %#04x, want %#04x", p.Words[3], wantBackward)
+	}
+}
+
+func TestAssembleDirectives(t *testing.T) {
+	src := `
+.ORIG x3000
+VAL     .FILL x00FF
+ARR     .BLKW 3
+MSG     .STRINGZ "hi"
+.END
+`
+	p, err := Assemble(src)
+	if err != nil {
+		t.Fatalf("Assemble: %v", err)
+	}
+
+	wantSymbols := map[string]uint16{"VAL": 0x3000, "ARR": 0x3001, "MSG": 0x3004}
+	for name, want := range wantSymbols {
+		if got := p.Symbols[name]; got != want {
+			t.Errorf("%s = %#04x, want %#04x", name, got, want)
+		}
+	}
+
+	wantWords := []uint16{
+		0x00FF,  // VAL
+		0, 0, 0, // ARR .BLKW 3
+		'h', 'i', 0, // MSG .STRINGZ "hi"
+	}
+	if len(p.Words) != len(wantWords) {
+		t.Fatalf("len(Words) = %d, want %d: %v", len(p.Words), len(wantWords), p.Words)
+	}
+	for i, want := range wantWords {
+		if p.Words[i] != want {
+			t.Errorf("Words[%d] = %#04x, want %#04x", i, p.Words[i], want)
+		}
+	}
+}
+
+func TestAssembleErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{
+			name:    "missing .ORIG",
+			src:     "ADD R0, R0, #1\n.END",
+			wantErr: ".ORIG must be the first statement",
+		},
+		{
+			name: "duplicate label",
+			src: `
+.ORIG x3000
+L   ADD R0, R0, #1
+L   ADD R0, R0, #1
+.END
+`,
+			wantErr: `duplicate label "L"`,
+		},
+		{
+			name: "undefined label",
+			src: `
+.ORIG x3000
+BR NOPE
+.END
+`,
+			wantErr: `undefined label "NOPE"`,
+		},
+		{
+			name: "5-bit immediate out of range",
+			src: `
+.ORIG x3000
+ADD R0, R0, #16
+.END
+`,
+			wantErr: "out of 5-bit range",
+		},
+		{
+			name: "6-bit offset out of range",
+			src: `
+.ORIG x3000
+LDR R0, R1, #32
+.END
+`,
+			wantErr: "out of 6-bit range",
+		},
+		{
+			name: "PC-relative label out of 9-bit range",
+			src: `
+.ORIG x3000
+BR FAR
+.BLKW 300
+FAR ADD R0, R0, #1
+.END
+`,
+			wantErr: "out of range for a 9-bit offset",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := Assemble(c.src)
+			if err == nil {
+				t.Fatalf("Assemble: got nil error, want one containing %q", c.wantErr)
+			}
+			if !strings.Contains(err.Error(), c.wantErr) {
+				t.Fatalf("Assemble error = %q, want it to contain %q", err.Error(), c.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,358 @@
+package asm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/BadgerBadgerBadgerBadger/go-lvc3-vm/lc3/vm"
+)
+
+// sizeOf returns how many words a statement occupies in the image.
+func sizeOf(s statement) (uint16, error) {
+	switch s.op {
+	case ".FILL":
+		return 1, nil
+	case ".STRINGZ":
+		str, err := parseString(s.operands)
+		if err != nil {
+			return 0, err
+		}
+		return uint16(len(str) + 1), nil
+	case ".BLKW":
+		if len(s.operands) != 1 {
+			return 0, fmt.Errorf(".BLKW takes exactly one operand")
+		}
+		n, err := strconv.ParseUint(s.operands[0], 0, 16)
+		if err != nil {
+			return 0, fmt.Errorf("invalid .BLKW count %q", s.operands[0])
+		}
+		return uint16(n), nil
+	default:
+		return 1, nil
+	}
+}
+
+// encode produces the word(s) for a statement. lc is the address the
+// statement is loaded at (needed for PC-relative label operands, where the
+// LC-3 convention is to offset from the address of the *following*
+// instruction).
+func encode(s statement, lc uint16, symbols map[string]uint16) ([]uint16, error) {
+
+	nextPc := lc + 1
+
+	switch {
+	case s.op == ".FILL":
+		v, err := parseImmediate(s.operands, 0)
+		if err != nil {
+			if target, ok := symbols[s.operands[0]]; ok {
+				return []uint16{target}, nil
+			}
+			return nil, err
+		}
+		return []uint16{v}, nil
+
+	case s.op == ".BLKW":
+		n, _ := sizeOf(s)
+		return make([]uint16, n), nil
+
+	case s.op == ".STRINGZ":
+		str, err := parseString(s.operands)
+		if err != nil {
+			return nil, err
+		}
+		words := make([]uint16, 0, len(str)+1)
+		for _, c := range str {
+			words = append(words, uint16(c))
+		}
+		return append(words, 0), nil
+
+	case s.op == "ADD" || s.op == "AND":
+		return encodeAddAnd(s)
+
+	case s.op == "NOT":
+		return encodeNot(s)
+
+	case s.op == "LD" || s.op == "LDI" || s.op == "LEA" || s.op == "ST" || s.op == "STI":
+		return encodePcOffset9(s, nextPc, symbols)
+
+	case s.op == "LDR" || s.op == "STR":
+		return encodeBaseOffset6(s)
+
+	case s.op == "JMP":
+		r, err := register(s.operands, 0)
+		if err != nil {
+			return nil, err
+		}
+		return []uint16{vm.OpJump<<12 | r<<6}, nil
+
+	case s.op == "RET":
+		return []uint16{vm.OpJump<<12 | 7<<6}, nil
+
+	case s.op == "JSR":
+		offset, err := pcOffset(s.operands, nextPc, symbols, 11)
+		if err != nil {
+			return nil, err
+		}
+		return []uint16{vm.OpJumpRegister<<12 | 1<<11 | offset&0x7ff}, nil
+
+	case s.op == "JSRR":
+		r, err := register(s.operands, 0)
+		if err != nil {
+			return nil, err
+		}
+		return []uint16{vm.OpJumpRegister<<12 | r<<6}, nil
+
+	case s.op == "RTI":
+		return []uint16{vm.OpRti << 12}, nil
+
+	case s.op == "TRAP":
+		if len(s.operands) != 1 {
+			return nil, fmt.Errorf("TRAP takes exactly one operand")
+		}
+		v, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimPrefix(s.operands[0], "x"), "X"), 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trap vector %q", s.operands[0])
+		}
+		return []uint16{vm.OpTrap<<12 | uint16(v)}, nil
+
+	case s.op == "GETC":
+		return []uint16{vm.OpTrap<<12 | vm.TrapGetC}, nil
+	case s.op == "OUT":
+		return []uint16{vm.OpTrap<<12 | vm.TrapOut}, nil
+	case s.op == "PUTS":
+		return []uint16{vm.OpTrap<<12 | vm.TrapPutS}, nil
+	case s.op == "IN":
+		return []uint16{vm.OpTrap<<12 | vm.TrapIn}, nil
+	case s.op == "PUTSP":
+		return []uint16{vm.OpTrap<<12 | vm.TrapPutSP}, nil
+	case s.op == "HALT":
+		return []uint16{vm.OpTrap<<12 | vm.TrapHalt}, nil
+
+	case strings.HasPrefix(s.op, "BR"):
+		return encodeBr(s, nextPc, symbols)
+	}
+
+	return nil, fmt.Errorf("unknown opcode %q", s.op)
+}
+
+func encodeAddAnd(s statement) ([]uint16, error) {
+	if len(s.operands) != 3 {
+		return nil, fmt.Errorf("%s takes exactly 3 operands", s.op)
+	}
+	dr, err := register(s.operands, 0)
+	if err != nil {
+		return nil, err
+	}
+	sr1, err := register(s.operands, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	opBits := uint16(vm.OpAdd)
+	if s.op == "AND" {
+		opBits = vm.OpAnd
+	}
+
+	if sr2, err := register(s.operands, 2); err == nil {
+		return []uint16{opBits<<12 | dr<<9 | sr1<<6 | sr2}, nil
+	}
+
+	imm, err := parseImmediate(s.operands, 2)
+	if err != nil {
+		return nil, fmt.Errorf("invalid operand %q", s.operands[2])
+	}
+	if imm > 0xf && imm < 0xfff0 {
+		return nil, fmt.Errorf("immediate %d out of 5-bit range", int16(imm))
+	}
+	return []uint16{opBits<<12 | dr<<9 | sr1<<6 | 1<<5 | imm&0x1f}, nil
+}
+
+func encodeNot(s statement) ([]uint16, error) {
+	if len(s.operands) != 2 {
+		return nil, fmt.Errorf("NOT takes exactly 2 operands")
+	}
+	dr, err := register(s.operands, 0)
+	if err != nil {
+		return nil, err
+	}
+	sr, err := register(s.operands, 1)
+	if err != nil {
+		return nil, err
+	}
+	return []uint16{vm.OpNot<<12 | dr<<9 | sr<<6 | 0x3f}, nil
+}
+
+func encodePcOffset9(s statement, nextPc uint16, symbols map[string]uint16) ([]uint16, error) {
+	if len(s.operands) != 2 {
+		return nil, fmt.Errorf("%s takes exactly 2 operands", s.op)
+	}
+	r, err := register(s.operands, 0)
+	if err != nil {
+		return nil, err
+	}
+	offset, err := pcOffset(s.operands[1:], nextPc, symbols, 9)
+	if err != nil {
+		return nil, err
+	}
+
+	var opBits uint16
+	switch s.op {
+	case "LD":
+		opBits = vm.OpLoad
+	case "LDI":
+		opBits = vm.OpLoadIndirect
+	case "LEA":
+		opBits = vm.OpLoadEffectiveAddress
+	case "ST":
+		opBits = vm.OpStore
+	case "STI":
+		opBits = vm.OpStoreIndirect
+	}
+
+	return []uint16{opBits<<12 | r<<9 | offset&0x1ff}, nil
+}
+
+func encodeBaseOffset6(s statement) ([]uint16, error) {
+	if len(s.operands) != 3 {
+		return nil, fmt.Errorf("%s takes exactly 3 operands", s.op)
+	}
+	r, err := register(s.operands, 0)
+	if err != nil {
+		return nil, err
+	}
+	base, err := register(s.operands, 1)
+	if err != nil {
+		return nil, err
+	}
+	offset, err := parseImmediate(s.operands, 2)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0x1f && offset < 0xffe0 {
+		return nil, fmt.Errorf("offset %d out of 6-bit range", int16(offset))
+	}
+
+	opBits := uint16(vm.OpLoadRegister)
+	if s.op == "STR" {
+		opBits = vm.OpStoreRegister
+	}
+
+	return []uint16{opBits<<12 | r<<9 | base<<6 | offset&0x3f}, nil
+}
+
+func encodeBr(s statement, nextPc uint16, symbols map[string]uint16) ([]uint16, error) {
+	flags := strings.TrimPrefix(s.op, "BR")
+	n, z, p := false, false, false
+	if flags == "" {
+		n, z, p = true, true, true
+	}
+	for _, c := range flags {
+		switch c {
+		case 'N', 'n':
+			n = true
+		case 'Z', 'z':
+			z = true
+		case 'P', 'p':
+			p = true
+		default:
+			return nil, fmt.Errorf("unknown branch condition %q", s.op)
+		}
+	}
+
+	offset, err := pcOffset(s.operands, nextPc, symbols, 9)
+	if err != nil {
+		return nil, err
+	}
+
+	var cond uint16
+	if n {
+		cond |= 1 << 2
+	}
+	if z {
+		cond |= 1 << 1
+	}
+	if p {
+		cond |= 1 << 0
+	}
+
+	return []uint16{vm.OpBreak<<12 | cond<<9 | offset&0x1ff}, nil
+}
+
+func register(operands []string, idx int) (uint16, error) {
+	if idx >= len(operands) {
+		return 0, fmt.Errorf("missing register operand")
+	}
+	name := strings.ToUpper(operands[idx])
+	r, ok := registerNames[name]
+	if !ok {
+		return 0, fmt.Errorf("invalid register %q", operands[idx])
+	}
+	return r, nil
+}
+
+func pcOffset(operands []string, nextPc uint16, symbols map[string]uint16, bits uint) (uint16, error) {
+	if len(operands) != 1 {
+		return 0, fmt.Errorf("expected a single label or immediate operand")
+	}
+	var target uint16
+	if t, ok := symbols[operands[0]]; ok {
+		target = t
+	} else {
+		imm, err := parseImmediate(operands, 0)
+		if err != nil {
+			return 0, fmt.Errorf("undefined label %q", operands[0])
+		}
+		target = nextPc + imm
+	}
+
+	offset := target - nextPc
+	max := int32(1<<(bits-1)) - 1
+	min := -int32(1 << (bits - 1))
+	if int32(int16(offset)) > max || int32(int16(offset)) < min {
+		return 0, fmt.Errorf("label %q is out of range for a %d-bit offset", operands[0], bits)
+	}
+
+	return offset, nil
+}
+
+// parseImmediate parses operands[idx] as an LC-3 immediate: #10 (decimal),
+// x1F/xA (hex), or a bare decimal.
+func parseImmediate(operands []string, idx int) (uint16, error) {
+	if idx >= len(operands) {
+		return 0, fmt.Errorf("missing immediate operand")
+	}
+	tok := operands[idx]
+
+	switch {
+	case strings.HasPrefix(tok, "#"):
+		v, err := strconv.ParseInt(tok[1:], 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid immediate %q", tok)
+		}
+		return uint16(v), nil
+	case strings.HasPrefix(tok, "x") || strings.HasPrefix(tok, "X"):
+		v, err := strconv.ParseInt(tok[1:], 16, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid immediate %q", tok)
+		}
+		return uint16(v), nil
+	default:
+		v, err := strconv.ParseInt(tok, 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid immediate %q", tok)
+		}
+		return uint16(v), nil
+	}
+}
+
+func parseString(operands []string) (string, error) {
+	if len(operands) != 1 {
+		return "", fmt.Errorf(".STRINGZ takes exactly one quoted operand")
+	}
+	tok := operands[0]
+	if len(tok) < 2 || tok[0] != '"' || tok[len(tok)-1] != '"' {
+		return "", fmt.Errorf(".STRINGZ operand must be a quoted string")
+	}
+	return strings.ReplaceAll(tok[1:len(tok)-1], `\n`, "\n"), nil
+}
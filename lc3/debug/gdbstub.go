@@ -0,0 +1,351 @@
+// Package debug implements a GDB Remote Serial Protocol server over TCP,
+// so a loaded VM can be driven with gdb-multiarch (or a custom LC-3 stub)
+// using stepi, info registers, x/, breakpoints, and continue.
+//
+// Registers are exposed in the order R0-R7, PC, COND, each as a 4-hex-digit
+// big-endian halfword; a gdb target description for a custom LC-3 arch
+// should declare them in that order. This package is a thin adapter: all
+// breakpoint and watchpoint state lives on lc3/vm.VM itself.
+package debug
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BadgerBadgerBadgerBadger/go-lvc3-vm/lc3/vm"
+)
+
+// Serve listens on addr and services GDB Remote Serial Protocol
+// connections against v, one at a time, until the listener is closed or
+// accepting fails.
+func Serve(v *vm.VM, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		handleConn(v, conn)
+	}
+}
+
+type session struct {
+	vm   *vm.VM
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func handleConn(v *vm.VM, conn net.Conn) {
+	defer conn.Close()
+
+	s := &session{vm: v, conn: conn, r: bufio.NewReader(conn)}
+
+	for {
+		packet, ok := s.readPacket()
+		if !ok {
+			return
+		}
+
+		reply := s.dispatch(packet)
+		s.sendPacket(reply)
+	}
+}
+
+// readPacket reads ack/nak bytes and a single '$...#cc' packet, replying
+// '+' once the checksum verifies. It returns ok=false on EOF or a
+// transport error.
+func (s *session) readPacket() (string, bool) {
+	for {
+		b, err := s.r.ReadByte()
+		if err != nil {
+			return "", false
+		}
+
+		switch b {
+		case '+', '-':
+			continue
+		case 0x03: // Ctrl-C between packets: nothing running to interrupt, so it's a no-op poke; continueExec handles it while Run is active
+			continue
+		case '$':
+			var body strings.Builder
+			for {
+				c, err := s.r.ReadByte()
+				if err != nil {
+					return "", false
+				}
+				if c == '#' {
+					break
+				}
+				body.WriteByte(c)
+			}
+
+			checksumBytes := make([]byte, 2)
+			if _, err := io.ReadFull(s.r, checksumBytes); err != nil {
+				return "", false
+			}
+			want, _ := strconv.ParseUint(string(checksumBytes), 16, 8)
+
+			if uint8(want) == checksum(body.String()) {
+				s.conn.Write([]byte{'+'})
+				return body.String(), true
+			}
+			s.conn.Write([]byte{'-'})
+		}
+	}
+}
+
+func (s *session) sendPacket(payload string) {
+	fmt.Fprintf(s.conn, "$%s#%02x", payload, checksum(payload))
+}
+
+func checksum(s string) uint8 {
+	var sum uint8
+	for i := 0; i < len(s); i++ {
+		sum += s[i]
+	}
+	return sum
+}
+
+// dispatch handles the minimal packet set needed for stepi, info
+// registers, x/, breakpoints, and continue: g/G, m/M, p/P, s, c, Z0/z0, ?,
+// and qSupported. Anything else gets an empty "unsupported" reply.
+func (s *session) dispatch(packet string) string {
+	switch {
+	case packet == "?":
+		if s.vm.Running {
+			return "S05"
+		}
+		return "W00"
+
+	case strings.HasPrefix(packet, "qSupported"):
+		return "PacketSize=4000"
+
+	case packet == "g":
+		return s.readAllRegisters()
+
+	case strings.HasPrefix(packet, "G"):
+		return s.writeAllRegisters(packet[1:])
+
+	case strings.HasPrefix(packet, "p"):
+		return s.readRegister(packet[1:])
+
+	case strings.HasPrefix(packet, "P"):
+		return s.writeRegister(packet[1:])
+
+	case strings.HasPrefix(packet, "m"):
+		return s.readMemory(packet[1:])
+
+	case strings.HasPrefix(packet, "M"):
+		return s.writeMemory(packet[1:])
+
+	case packet == "s":
+		return s.stopReply(s.vm.StepInstruction())
+
+	case packet == "c":
+		return s.stopReply(s.continueExec())
+
+	case strings.HasPrefix(packet, "Z0,"):
+		return s.setBreakpoint(packet[len("Z0,"):])
+
+	case strings.HasPrefix(packet, "z0,"):
+		return s.clearBreakpoint(packet[len("z0,"):])
+
+	default:
+		return ""
+	}
+}
+
+// continueExec steps over a breakpoint sitting at the current PC (the
+// usual GDB idiom: a still-armed breakpoint must not immediately re-trip
+// the instruction it was set on) before resuming.
+//
+// While Run is executing, nothing else is reading the connection, so a
+// background goroutine takes over s.r to watch for GDB's Ctrl-C byte and
+// feed it to Run's stop channel — otherwise a genuine target infinite
+// loop could never be interrupted. The goroutine is reaped before
+// returning, via a read deadline that unblocks its pending ReadByte if
+// Run already stopped on its own.
+func (s *session) continueExec() vm.StopReason {
+	if s.vm.HasBreakpoint(s.vm.Registers[vm.RPc]) {
+		if reason := s.vm.StepInstruction(); reason == vm.StopHalt {
+			return reason
+		}
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			b, err := s.r.ReadByte()
+			if err != nil {
+				return
+			}
+			if b == 0x03 {
+				close(stop)
+				return
+			}
+		}
+	}()
+
+	reason := s.vm.Run(stop)
+
+	s.conn.SetReadDeadline(time.Now())
+	<-done
+	s.conn.SetReadDeadline(time.Time{})
+
+	return reason
+}
+
+func (s *session) stopReply(reason vm.StopReason) string {
+	if reason == vm.StopHalt {
+		return "W00"
+	}
+	return "S05" // SIGTRAP: breakpoint, single-step, or external stop
+}
+
+var registerOrder = []int{vm.R0, vm.R1, vm.R2, vm.R3, vm.R4, vm.R5, vm.R6, vm.R7, vm.RPc, vm.RCond}
+
+func (s *session) readAllRegisters() string {
+	var out strings.Builder
+	for _, r := range registerOrder {
+		fmt.Fprintf(&out, "%04x", s.vm.Registers[r])
+	}
+	return out.String()
+}
+
+func (s *session) writeAllRegisters(hexData string) string {
+	for i, r := range registerOrder {
+		if (i+1)*4 > len(hexData) {
+			break
+		}
+		v, err := strconv.ParseUint(hexData[i*4:i*4+4], 16, 16)
+		if err != nil {
+			return "E01"
+		}
+		s.vm.Registers[r] = uint16(v)
+	}
+	return "OK"
+}
+
+func (s *session) readRegister(hexIndex string) string {
+	idx, err := strconv.ParseUint(hexIndex, 16, 8)
+	if err != nil || int(idx) >= len(registerOrder) {
+		return "E01"
+	}
+	return fmt.Sprintf("%04x", s.vm.Registers[registerOrder[idx]])
+}
+
+func (s *session) writeRegister(arg string) string {
+	parts := strings.SplitN(arg, "=", 2)
+	if len(parts) != 2 {
+		return "E01"
+	}
+	idx, err := strconv.ParseUint(parts[0], 16, 8)
+	if err != nil || int(idx) >= len(registerOrder) {
+		return "E01"
+	}
+	val, err := strconv.ParseUint(parts[1], 16, 16)
+	if err != nil {
+		return "E01"
+	}
+	s.vm.Registers[registerOrder[idx]] = uint16(val)
+	return "OK"
+}
+
+// readMemory handles "m addr,length". addr is a word address on the
+// bus; length is in bytes, rounded up to whole words.
+func (s *session) readMemory(arg string) string {
+	addr, length, ok := parseAddrLength(arg)
+	if !ok {
+		return "E01"
+	}
+
+	var out strings.Builder
+	read := 0
+	for a := addr; read < length; a++ {
+		if int(a) >= vm.MemSize {
+			break
+		}
+		fmt.Fprintf(&out, "%04x", s.vm.Bus.Read(a))
+		read += 2
+	}
+	return out.String()
+}
+
+func (s *session) writeMemory(arg string) string {
+	header, data, found := strings.Cut(arg, ":")
+	if !found {
+		return "E01"
+	}
+	addr, length, ok := parseAddrLength(header)
+	if !ok {
+		return "E01"
+	}
+
+	word := 0
+	for i := 0; i+4 <= len(data) && word*2 < length; i += 4 {
+		v, err := strconv.ParseUint(data[i:i+4], 16, 16)
+		if err != nil {
+			return "E01"
+		}
+		if int(addr)+word >= vm.MemSize {
+			break
+		}
+		s.vm.Bus.Write(uint16(int(addr)+word), uint16(v))
+		word++
+	}
+	return "OK"
+}
+
+func parseAddrLength(arg string) (addr uint16, length int, ok bool) {
+	parts := strings.SplitN(arg, ",", 2)
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	a, err := strconv.ParseUint(parts[0], 16, 16)
+	if err != nil {
+		return 0, 0, false
+	}
+	l, err := strconv.ParseUint(parts[1], 16, 32)
+	if err != nil {
+		return 0, 0, false
+	}
+	return uint16(a), int(l), true
+}
+
+func (s *session) setBreakpoint(arg string) string {
+	addr, _, found := strings.Cut(arg, ",")
+	if !found {
+		return "E01"
+	}
+	a, err := strconv.ParseUint(addr, 16, 16)
+	if err != nil {
+		return "E01"
+	}
+	s.vm.SetBreakpoint(uint16(a))
+	return "OK"
+}
+
+func (s *session) clearBreakpoint(arg string) string {
+	addr, _, found := strings.Cut(arg, ",")
+	if !found {
+		return "E01"
+	}
+	a, err := strconv.ParseUint(addr, 16, 16)
+	if err != nil {
+		return "E01"
+	}
+	s.vm.ClearBreakpoint(uint16(a))
+	return "OK"
+}
@@ -0,0 +1,149 @@
+package debug
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BadgerBadgerBadgerBadger/go-lvc3-vm/lc3/vm"
+)
+
+type stubIO struct{}
+
+func (stubIO) CheckKey() bool  { return false }
+func (stubIO) GetChar() uint16 { return 0 }
+func (stubIO) Out(byte)        {}
+
+func newTestSession(t *testing.T) (*session, net.Conn) {
+	t.Helper()
+	server, client := net.Pipe()
+	t.Cleanup(func() { server.Close(); client.Close() })
+	v := vm.New(vm.NewSystemBus(), stubIO{})
+	return &session{vm: v, conn: server, r: bufio.NewReader(server)}, client
+}
+
+// TestReadPacketChecksumSplitAcrossReads reproduces a checksum byte pair
+// delivered in two separate writes, the way it can arrive split across TCP
+// segments on a real connection: readPacket must still read both checksum
+// bytes before comparing, not just whatever the first read happened to
+// return.
+func TestReadPacketChecksumSplitAcrossReads(t *testing.T) {
+	s, client := newTestSession(t)
+
+	go func() {
+		client.Write([]byte("$?#3"))
+		time.Sleep(10 * time.Millisecond)
+		client.Write([]byte("f"))
+	}()
+
+	ack := make(chan byte, 1)
+	go func() {
+		b := make([]byte, 1)
+		client.SetReadDeadline(time.Now().Add(time.Second))
+		if _, err := client.Read(b); err == nil {
+			ack <- b[0]
+		}
+	}()
+
+	packet, ok := s.readPacket()
+	if !ok {
+		t.Fatal("readPacket() ok = false, want true")
+	}
+	if packet != "?" {
+		t.Fatalf("packet = %q, want %q", packet, "?")
+	}
+
+	select {
+	case got := <-ack:
+		if got != '+' {
+			t.Fatalf("ack = %q, want '+' (a correct checksum must not be NAKed)", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("never received an ack byte")
+	}
+}
+
+func TestDispatchRegisters(t *testing.T) {
+	s, _ := newTestSession(t)
+	s.vm.Registers[vm.R0] = 0x1234
+
+	if got, want := s.dispatch("g")[:4], "1234"; got != want {
+		t.Errorf("g R0 = %q, want %q", got, want)
+	}
+
+	reply := s.dispatch("G" + strings.Repeat("0", len(registerOrder)*4-4) + "beef")
+	if reply != "OK" {
+		t.Fatalf("G reply = %q, want OK", reply)
+	}
+	last := registerOrder[len(registerOrder)-1]
+	if s.vm.Registers[last] != 0xbeef {
+		t.Errorf("last register = %#04x, want 0xbeef", s.vm.Registers[last])
+	}
+}
+
+func TestDispatchMemory(t *testing.T) {
+	s, _ := newTestSession(t)
+	s.vm.Bus.Write(0x3000, 0xCAFE)
+
+	if got, want := s.dispatch("m3000,2"), "cafe"; got != want {
+		t.Errorf("m3000,2 = %q, want %q", got, want)
+	}
+
+	if got := s.dispatch("M3000,2:beef"); got != "OK" {
+		t.Fatalf("M3000,2:beef = %q, want OK", got)
+	}
+	if got := s.vm.Bus.Read(0x3000); got != 0xbeef {
+		t.Errorf("Bus.Read(0x3000) = %#04x, want 0xbeef", got)
+	}
+}
+
+func TestDispatchBreakpoints(t *testing.T) {
+	s, _ := newTestSession(t)
+
+	if got := s.dispatch("Z0,3000,1"); got != "OK" {
+		t.Fatalf("Z0,3000,1 = %q, want OK", got)
+	}
+	if !s.vm.HasBreakpoint(0x3000) {
+		t.Fatal("HasBreakpoint(0x3000) = false after setting it")
+	}
+
+	if got := s.dispatch("z0,3000,1"); got != "OK" {
+		t.Fatalf("z0,3000,1 = %q, want OK", got)
+	}
+	if s.vm.HasBreakpoint(0x3000) {
+		t.Fatal("HasBreakpoint(0x3000) = true after clearing it")
+	}
+}
+
+// TestContinueExecInterruptedByCtrlC drives a genuinely infinite target
+// loop through continueExec and confirms that writing GDB's Ctrl-C byte
+// (0x03) to the connection stops it, rather than leaving it to run until
+// the process exits.
+func TestContinueExecInterruptedByCtrlC(t *testing.T) {
+	s, client := newTestSession(t)
+
+	// BR self: an unconditional branch back to its own address. A fresh VM's
+	// RCond is 0, which no BR flag matches, so set Z for BRnzp to be taken.
+	s.vm.Registers[vm.RCond] = vm.FlagZero
+	s.vm.Bus.Write(vm.PcStart, vm.OpBreak<<12|0x7<<9|uint16(0x1ff))
+
+	done := make(chan vm.StopReason, 1)
+	go func() { done <- s.continueExec() }()
+
+	// Give Run a moment to actually be spinning before interrupting it.
+	time.Sleep(20 * time.Millisecond)
+	if _, err := client.Write([]byte{0x03}); err != nil {
+		t.Fatalf("writing Ctrl-C: %v", err)
+	}
+
+	select {
+	case reason := <-done:
+		if reason != vm.StopSignal {
+			t.Errorf("continueExec() = %v, want StopSignal", reason)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("continueExec did not return after Ctrl-C; Run was never interrupted")
+	}
+}
@@ -0,0 +1,159 @@
+// Package disasm turns loaded LC-3 memory back into an annotated mnemonic
+// listing, the inverse of lc3/asm.
+package disasm
+
+import (
+	"fmt"
+
+	"github.com/BadgerBadgerBadgerBadger/go-lvc3-vm/lc3/vm"
+)
+
+// Line is one disassembled instruction: its address, the raw word, the
+// mnemonic text rendered for it, and the label at that address, if any.
+type Line struct {
+	Address uint16
+	Word    uint16
+	Text    string
+	Label   string
+}
+
+// Disassemble renders memory[start:end) as a sequence of annotated lines.
+func Disassemble(memory []uint16, start, end uint16) []Line {
+	return DisassembleWithSymbols(memory, start, end, nil)
+}
+
+// DisassembleWithSymbols is Disassemble, additionally labelling each line
+// whose address appears in symbols — the label -> address table a
+// lc3/asm.Program or a restored vm.VM's Symbols field carries.
+func DisassembleWithSymbols(memory []uint16, start, end uint16, symbols map[string]uint16) []Line {
+
+	byAddr := make(map[uint16]string, len(symbols))
+	for name, addr := range symbols {
+		byAddr[addr] = name
+	}
+
+	lines := make([]Line, 0, int(end)-int(start))
+
+	for addr := start; addr < end; addr++ {
+		word := memory[addr]
+		lines = append(lines, Line{Address: addr, Word: word, Text: mnemonic(addr, word), Label: byAddr[addr]})
+	}
+
+	return lines
+}
+
+func mnemonic(addr, instruction uint16) string {
+
+	op := instruction >> 12
+	dr := (instruction >> 9) & 0x7
+	sr1 := (instruction >> 6) & 0x7
+	sr2 := instruction & 0x7
+	imm5 := int16(signExtend(instruction&0x1f, 5))
+	pcOffset9 := int16(signExtend(instruction&0x1ff, 9))
+	pcOffset11 := int16(signExtend(instruction&0x7ff, 11))
+	offset6 := int16(signExtend(instruction&0x3f, 6))
+
+	target9 := uint16(int32(addr) + 1 + int32(pcOffset9))
+	target11 := uint16(int32(addr) + 1 + int32(pcOffset11))
+
+	switch op {
+	case vm.OpBreak:
+		n, z, p := (instruction>>11)&1, (instruction>>10)&1, (instruction>>9)&1
+		flags := ""
+		if n == 1 {
+			flags += "n"
+		}
+		if z == 1 {
+			flags += "z"
+		}
+		if p == 1 {
+			flags += "p"
+		}
+		return fmt.Sprintf("BR%s #%d ; -> x%04X", flags, pcOffset9, target9)
+
+	case vm.OpAdd:
+		if (instruction>>5)&1 == 1 {
+			return fmt.Sprintf("ADD R%d, R%d, #%d", dr, sr1, imm5)
+		}
+		return fmt.Sprintf("ADD R%d, R%d, R%d", dr, sr1, sr2)
+
+	case vm.OpLoad:
+		return fmt.Sprintf("LD R%d, #%d ; -> x%04X", dr, pcOffset9, target9)
+
+	case vm.OpStore:
+		return fmt.Sprintf("ST R%d, #%d ; -> x%04X", dr, pcOffset9, target9)
+
+	case vm.OpJumpRegister:
+		if (instruction>>11)&1 == 1 {
+			return fmt.Sprintf("JSR #%d ; -> x%04X", pcOffset11, target11)
+		}
+		return fmt.Sprintf("JSRR R%d", sr1)
+
+	case vm.OpAnd:
+		if (instruction>>5)&1 == 1 {
+			return fmt.Sprintf("AND R%d, R%d, #%d", dr, sr1, imm5)
+		}
+		return fmt.Sprintf("AND R%d, R%d, R%d", dr, sr1, sr2)
+
+	case vm.OpLoadRegister:
+		return fmt.Sprintf("LDR R%d, R%d, #%d", dr, sr1, offset6)
+
+	case vm.OpStoreRegister:
+		return fmt.Sprintf("STR R%d, R%d, #%d", dr, sr1, offset6)
+
+	case vm.OpRti:
+		return "RTI"
+
+	case vm.OpNot:
+		return fmt.Sprintf("NOT R%d, R%d", dr, sr1)
+
+	case vm.OpLoadIndirect:
+		return fmt.Sprintf("LDI R%d, #%d ; -> x%04X", dr, pcOffset9, target9)
+
+	case vm.OpStoreIndirect:
+		return fmt.Sprintf("STI R%d, #%d ; -> x%04X", dr, pcOffset9, target9)
+
+	case vm.OpJump:
+		if sr1 == 7 {
+			return "RET"
+		}
+		return fmt.Sprintf("JMP R%d", sr1)
+
+	case vm.OpRes:
+		return ".WORD ; reserved opcode"
+
+	case vm.OpLoadEffectiveAddress:
+		return fmt.Sprintf("LEA R%d, #%d ; -> x%04X", dr, pcOffset9, target9)
+
+	case vm.OpTrap:
+		return trapMnemonic(instruction & 0xff)
+	}
+
+	return fmt.Sprintf(".FILL x%04X", instruction)
+}
+
+func trapMnemonic(vector uint16) string {
+	switch vector {
+	case vm.TrapGetC:
+		return "GETC"
+	case vm.TrapOut:
+		return "OUT"
+	case vm.TrapPutS:
+		return "PUTS"
+	case vm.TrapIn:
+		return "IN"
+	case vm.TrapPutSP:
+		return "PUTSP"
+	case vm.TrapHalt:
+		return "HALT"
+	default:
+		return fmt.Sprintf("TRAP x%02X", vector)
+	}
+}
+
+func signExtend(num uint16, bitCount uint) uint16 {
+	if (num>>(bitCount-1))&1 == 1 {
+		return num | (0xffff << bitCount)
+	}
+	return num
+}
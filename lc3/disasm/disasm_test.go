@@ -0,0 +1,73 @@
+package disasm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/BadgerBadgerBadgerBadger/go-lvc3-vm/lc3/vm"
+)
+
+func TestMnemonicPerOpcode(t *testing.T) {
+	cases := []struct {
+		name        string
+		instruction uint16
+		want        string
+	}{
+		{"BR", vm.OpBreak<<12 | 0x7<<9 | 2, "BRnzp #2 ; -> x3003"},
+		{"ADD register", vm.OpAdd<<12 | 1<<9 | 2<<6 | 3, "ADD R1, R2, R3"},
+		{"ADD immediate", vm.OpAdd<<12 | 1<<9 | 2<<6 | 1<<5 | 0x1f, "ADD R1, R2, #-1"},
+		{"LD", vm.OpLoad<<12 | 3<<9 | 2, "LD R3, #2 ; -> x3003"},
+		{"ST", vm.OpStore<<12 | 3<<9 | 2, "ST R3, #2 ; -> x3003"},
+		{"JSR", vm.OpJumpRegister<<12 | 1<<11 | 0x7ff, "JSR #-1 ; -> x3000"},
+		{"JSRR", vm.OpJumpRegister<<12 | 4<<6, "JSRR R4"},
+		{"AND register", vm.OpAnd<<12 | 1<<9 | 2<<6 | 3, "AND R1, R2, R3"},
+		{"AND immediate", vm.OpAnd<<12 | 1<<9 | 2<<6 | 1<<5 | 0, "AND R1, R2, #0"},
+		{"LDR", vm.OpLoadRegister<<12 | 1<<9 | 2<<6 | 3, "LDR R1, R2, #3"},
+		{"STR", vm.OpStoreRegister<<12 | 1<<9 | 2<<6 | 3, "STR R1, R2, #3"},
+		{"RTI", vm.OpRti << 12, "RTI"},
+		{"NOT", vm.OpNot<<12 | 1<<9 | 2<<6, "NOT R1, R2"},
+		{"LDI", vm.OpLoadIndirect<<12 | 3<<9 | 2, "LDI R3, #2 ; -> x3003"},
+		{"STI", vm.OpStoreIndirect<<12 | 3<<9 | 2, "STI R3, #2 ; -> x3003"},
+		{"JMP", vm.OpJump<<12 | 3<<6, "JMP R3"},
+		{"RET", vm.OpJump<<12 | 7<<6, "RET"},
+		{"reserved opcode", vm.OpRes << 12, ".WORD ; reserved opcode"},
+		{"LEA", vm.OpLoadEffectiveAddress<<12 | 3<<9 | 2, "LEA R3, #2 ; -> x3003"},
+		{"GETC", vm.OpTrap<<12 | vm.TrapGetC, "GETC"},
+		{"OUT", vm.OpTrap<<12 | vm.TrapOut, "OUT"},
+		{"PUTS", vm.OpTrap<<12 | vm.TrapPutS, "PUTS"},
+		{"IN", vm.OpTrap<<12 | vm.TrapIn, "IN"},
+		{"PUTSP", vm.OpTrap<<12 | vm.TrapPutSP, "PUTSP"},
+		{"HALT", vm.OpTrap<<12 | vm.TrapHalt, "HALT"},
+		{"unassigned trap vector", vm.OpTrap<<12 | 0x30, "TRAP x30"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := mnemonic(0x3000, c.instruction)
+			if got != c.want {
+				t.Errorf("mnemonic(x3000, %#04x) = %q, want %q", c.instruction, got, c.want)
+			}
+		})
+	}
+}
+
+func TestDisassembleWithSymbolsLabelsMatchingAddresses(t *testing.T) {
+	var memory [vm.MemSize]uint16
+	memory[0x3000] = vm.OpAdd<<12 | 1<<5
+	memory[0x3001] = vm.OpTrap<<12 | vm.TrapHalt
+	symbols := map[string]uint16{"START": 0x3000, "DONE": 0x3001}
+
+	lines := DisassembleWithSymbols(memory[:], 0x3000, 0x3002, symbols)
+	if len(lines) != 2 {
+		t.Fatalf("len(lines) = %d, want 2", len(lines))
+	}
+	if lines[0].Label != "START" {
+		t.Errorf("lines[0].Label = %q, want START", lines[0].Label)
+	}
+	if lines[1].Label != "DONE" {
+		t.Errorf("lines[1].Label = %q, want DONE", lines[1].Label)
+	}
+	if !strings.Contains(lines[1].Text, "HALT") {
+		t.Errorf("lines[1].Text = %q, want it to contain HALT", lines[1].Text)
+	}
+}
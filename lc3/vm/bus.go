@@ -0,0 +1,92 @@
+package vm
+
+// Bus is what memRead/memWrite dispatch every access through. The default
+// SystemBus composes a RAM device with whatever MMIO devices are attached,
+// but embedders can supply their own Bus entirely (a bus backed by a
+// remote debugger, a fuzzer harness, whatever fits).
+type Bus interface {
+	Read(addr uint16) uint16
+	Write(addr uint16, val uint16)
+}
+
+// Device is something that can claim a subset of the address space off a
+// Bus — RAM, a peripheral's control/data registers, or anything else a
+// user wants to extend the machine with.
+type Device interface {
+	// Claims reports whether this device owns addr.
+	Claims(addr uint16) bool
+	Read(addr uint16) uint16
+	Write(addr uint16, val uint16)
+}
+
+// SystemBus is the default Bus: a RAM device plus any number of attached
+// peripherals. Devices attached later take priority over earlier ones for
+// addresses they both claim, so a peripheral can be layered over RAM's
+// catch-all range without RAM needing to know about it.
+type SystemBus struct {
+	devices []Device
+	ram     *RAM
+}
+
+// NewSystemBus returns a bus with a full-address-space RAM device already
+// attached.
+func NewSystemBus() *SystemBus {
+	ram := &RAM{}
+	return &SystemBus{devices: []Device{ram}, ram: ram}
+}
+
+// Attach registers a device on the bus. Devices are consulted most-recently
+// attached first.
+func (b *SystemBus) Attach(d Device) {
+	b.devices = append(b.devices, d)
+}
+
+func (b *SystemBus) Read(addr uint16) uint16 {
+	for i := len(b.devices) - 1; i >= 0; i-- {
+		if b.devices[i].Claims(addr) {
+			return b.devices[i].Read(addr)
+		}
+	}
+	return 0
+}
+
+func (b *SystemBus) Write(addr uint16, val uint16) {
+	for i := len(b.devices) - 1; i >= 0; i-- {
+		if b.devices[i].Claims(addr) {
+			b.devices[i].Write(addr, val)
+			return
+		}
+	}
+}
+
+// Snapshot returns a copy of the bus's RAM contents, bypassing Device.Read
+// so that hashing or checkpointing memory never trips a peripheral's
+// access side effects (a keyboard poll, say).
+func (b *SystemBus) Snapshot() [MemSize]uint16 {
+	return b.ram.mem
+}
+
+// Restore overwrites the bus's RAM contents, bypassing Device.Write for
+// the same reason Snapshot bypasses Read.
+func (b *SystemBus) Restore(mem [MemSize]uint16) {
+	b.ram.mem = mem
+}
+
+// snapshotter is implemented by buses that can produce a side-effect-free
+// memory snapshot; SystemBus is the only built-in one. Custom Bus
+// implementations that don't implement it fall back to plain Reads.
+type snapshotter interface {
+	Snapshot() [MemSize]uint16
+}
+
+func snapshotMemory(bus Bus) [MemSize]uint16 {
+	if s, ok := bus.(snapshotter); ok {
+		return s.Snapshot()
+	}
+
+	var mem [MemSize]uint16
+	for addr := 0; addr < MemSize; addr++ {
+		mem[addr] = bus.Read(uint16(addr))
+	}
+	return mem
+}
@@ -0,0 +1,64 @@
+package vm
+
+// Breakpoints and watchpoints are first-class VM state rather than
+// something bolted onto a debug server: lc3/debug's GDB stub is a thin
+// adapter over the methods below, and any other embedder (a TUI, a test
+// harness) can use the same API directly.
+
+// watchpoint fires onAccess for every memRead/memWrite touching
+// [start, end).
+type watchpoint struct {
+	id       int
+	start    uint16
+	end      uint16
+	onAccess func(address uint16, write bool, value uint16)
+}
+
+// SetBreakpoint marks addr so that Run stops before executing the
+// instruction there. Breakpoints are a side table keyed by address; they
+// never touch the bus, so a loaded image is never mutated to plant one.
+func (v *VM) SetBreakpoint(addr uint16) {
+	if v.Breakpoints == nil {
+		v.Breakpoints = map[uint16]struct{}{}
+	}
+	v.Breakpoints[addr] = struct{}{}
+}
+
+// ClearBreakpoint removes a previously set breakpoint, if any.
+func (v *VM) ClearBreakpoint(addr uint16) {
+	delete(v.Breakpoints, addr)
+}
+
+// HasBreakpoint reports whether addr currently has a breakpoint set.
+func (v *VM) HasBreakpoint(addr uint16) bool {
+	_, ok := v.Breakpoints[addr]
+	return ok
+}
+
+// AddWatchpoint registers onAccess to be called for every memory access in
+// [start, end), read or write, and returns an id for RemoveWatchpoint.
+func (v *VM) AddWatchpoint(start, end uint16, onAccess func(address uint16, write bool, value uint16)) int {
+	id := v.nextWatchID
+	v.nextWatchID++
+	v.watchpoints = append(v.watchpoints, watchpoint{id: id, start: start, end: end, onAccess: onAccess})
+	return id
+}
+
+// RemoveWatchpoint removes a watchpoint previously returned by
+// AddWatchpoint. It is a no-op if id is unknown.
+func (v *VM) RemoveWatchpoint(id int) {
+	for i, w := range v.watchpoints {
+		if w.id == id {
+			v.watchpoints = append(v.watchpoints[:i], v.watchpoints[i+1:]...)
+			return
+		}
+	}
+}
+
+func (v *VM) checkWatchpoints(address uint16, write bool, value uint16) {
+	for _, w := range v.watchpoints {
+		if address >= w.start && address < w.end && w.onAccess != nil {
+			w.onAccess(address, write, value)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package vm
+
+// role identifies what a decoded bit field means to the instruction that
+// owns it.
+type role int
+
+const (
+	roleDR         role = iota // bits [11:9]: destination register, or the register field of a store
+	roleSR1                    // bits [8:6]: first source register / base register
+	roleSR2                    // bits [2:0]: second source register
+	roleImm5                   // bits [4:0]: 5-bit signed immediate
+	roleImmFlag                // bit [5]: ADD/AND immediate-mode flag
+	roleOffset6                // bits [5:0]: 6-bit signed offset (LDR/STR)
+	rolePCOffset9              // bits [8:0]: 9-bit signed PC-relative offset
+	rolePCOffset11             // bits [10:0]: 11-bit signed PC-relative offset
+	roleTrapVec                // bits [7:0]: trap vector
+	roleCondN                  // bit [11]: BR negative flag
+	roleCondZ                  // bit [10]: BR zero flag
+	roleCondP                  // bit [9]: BR positive flag
+	roleLongFlag               // bit [11]: JSR (1) vs JSRR (0)
+)
+
+// field describes where a value lives in a 16-bit instruction word: its bit
+// offset, width, whether it should be sign-extended, and what it means.
+type field struct {
+	offset uint
+	width  uint
+	signed bool
+	role   role
+}
+
+// instFormat is one row of the decode table: a mask/value pair that
+// identifies the encoding, the Op it decodes to, and the fields to extract
+// from a matching word. Adding a new encoding (an LC-3b extension, a new
+// pseudo-op) is a matter of adding a row here rather than editing the
+// execute switch.
+type instFormat struct {
+	mask   uint16
+	value  uint16
+	op     int
+	fields []field
+}
+
+var decodeTable = []instFormat{
+	{mask: 0xF000, value: 0x0000, op: OpBreak, fields: []field{
+		{11, 1, false, roleCondN}, {10, 1, false, roleCondZ}, {9, 1, false, roleCondP},
+		{0, 9, true, rolePCOffset9},
+	}},
+	{mask: 0xF020, value: 0x1000, op: OpAdd, fields: []field{
+		{9, 3, false, roleDR}, {6, 3, false, roleSR1}, {0, 3, false, roleSR2},
+	}},
+	{mask: 0xF020, value: 0x1020, op: OpAdd, fields: []field{
+		{9, 3, false, roleDR}, {6, 3, false, roleSR1}, {0, 5, true, roleImm5}, {5, 1, false, roleImmFlag},
+	}},
+	{mask: 0xF000, value: 0x2000, op: OpLoad, fields: []field{
+		{9, 3, false, roleDR}, {0, 9, true, rolePCOffset9},
+	}},
+	{mask: 0xF000, value: 0x3000, op: OpStore, fields: []field{
+		{9, 3, false, roleDR}, {0, 9, true, rolePCOffset9},
+	}},
+	{mask: 0xF800, value: 0x4800, op: OpJumpRegister, fields: []field{
+		{11, 1, false, roleLongFlag}, {0, 11, true, rolePCOffset11},
+	}},
+	{mask: 0xF800, value: 0x4000, op: OpJumpRegister, fields: []field{
+		{11, 1, false, roleLongFlag}, {6, 3, false, roleSR1},
+	}},
+	{mask: 0xF020, value: 0x5000, op: OpAnd, fields: []field{
+		{9, 3, false, roleDR}, {6, 3, false, roleSR1}, {0, 3, false, roleSR2},
+	}},
+	{mask: 0xF020, value: 0x5020, op: OpAnd, fields: []field{
+		{9, 3, false, roleDR}, {6, 3, false, roleSR1}, {0, 5, true, roleImm5}, {5, 1, false, roleImmFlag},
+	}},
+	{mask: 0xF000, value: 0x6000, op: OpLoadRegister, fields: []field{
+		{9, 3, false, roleDR}, {6, 3, false, roleSR1}, {0, 6, true, roleOffset6},
+	}},
+	{mask: 0xF000, value: 0x7000, op: OpStoreRegister, fields: []field{
+		{9, 3, false, roleDR}, {6, 3, false, roleSR1}, {0, 6, true, roleOffset6},
+	}},
+	{mask: 0xF000, value: 0x8000, op: OpRti, fields: nil},
+	{mask: 0xF03F, value: 0x903F, op: OpNot, fields: []field{
+		{9, 3, false, roleDR}, {6, 3, false, roleSR1},
+	}},
+	{mask: 0xF000, value: 0xA000, op: OpLoadIndirect, fields: []field{
+		{9, 3, false, roleDR}, {0, 9, true, rolePCOffset9},
+	}},
+	{mask: 0xF000, value: 0xB000, op: OpStoreIndirect, fields: []field{
+		{9, 3, false, roleDR}, {0, 9, true, rolePCOffset9},
+	}},
+	{mask: 0xF000, value: 0xC000, op: OpJump, fields: []field{
+		{6, 3, false, roleSR1},
+	}},
+	{mask: 0xF000, value: 0xD000, op: OpRes, fields: nil},
+	{mask: 0xF000, value: 0xE000, op: OpLoadEffectiveAddress, fields: []field{
+		{9, 3, false, roleDR}, {0, 9, true, rolePCOffset9},
+	}},
+	{mask: 0xF000, value: 0xF000, op: OpTrap, fields: []field{
+		{0, 8, false, roleTrapVec},
+	}},
+}
+
+// Args holds the typed operands extracted from a decoded instruction. Which
+// fields are meaningful depends on Op; unused fields are left zero.
+type Args struct {
+	DR, SR1, SR2        uint16
+	Imm5                int16
+	ImmFlag             bool
+	Offset6             int16
+	PCOffset9           int16
+	PCOffset11          int16
+	TrapVec             uint16
+	CondN, CondZ, CondP bool
+	Long                bool
+}
+
+// Inst is a decoded instruction: its opcode and its operands.
+type Inst struct {
+	Op   int
+	Args Args
+}
+
+// bits extracts width bits of word starting at offset, sign-extending the
+// result when signed is set.
+func bits(word uint16, offset, width uint, signed bool) uint16 {
+	mask := uint16((1 << width) - 1)
+	v := (word >> offset) & mask
+	if signed {
+		return signExtend(v, width)
+	}
+	return v
+}
+
+// decode scans the table linearly, ANDing word with each entry's mask and
+// comparing against its value, and extracts the matched entry's fields into
+// an Inst. A word that matches no row (a NOT whose low six bits aren't all
+// ones) decodes to OpRes, so decode never fails.
+func decode(word uint16) Inst {
+
+	for _, f := range decodeTable {
+		if word&f.mask != f.value {
+			continue
+		}
+
+		inst := Inst{Op: f.op}
+
+		for _, fl := range f.fields {
+			v := bits(word, fl.offset, fl.width, fl.signed)
+			switch fl.role {
+			case roleDR:
+				inst.Args.DR = v
+			case roleSR1:
+				inst.Args.SR1 = v
+			case roleSR2:
+				inst.Args.SR2 = v
+			case roleImm5:
+				inst.Args.Imm5 = int16(v)
+			case roleImmFlag:
+				inst.Args.ImmFlag = v == 1
+			case roleOffset6:
+				inst.Args.Offset6 = int16(v)
+			case rolePCOffset9:
+				inst.Args.PCOffset9 = int16(v)
+			case rolePCOffset11:
+				inst.Args.PCOffset11 = int16(v)
+			case roleTrapVec:
+				inst.Args.TrapVec = v
+			case roleCondN:
+				inst.Args.CondN = v == 1
+			case roleCondZ:
+				inst.Args.CondZ = v == 1
+			case roleCondP:
+				inst.Args.CondP = v == 1
+			case roleLongFlag:
+				inst.Args.Long = v == 1
+			}
+		}
+
+		return inst
+	}
+
+	// Only reached by an opcode-9 word without NOT's required low bits:
+	// every other opcode has a row matching all of its encodings.
+	return Inst{Op: OpRes}
+}
@@ -0,0 +1,81 @@
+package vm
+
+import "testing"
+
+func TestDecode(t *testing.T) {
+	cases := []struct {
+		name string
+		word uint16
+		want Inst
+	}{
+		{
+			name: "ADD register mode",
+			word: OpAdd<<12 | 1<<9 | 2<<6 | 3,
+			want: Inst{Op: OpAdd, Args: Args{DR: 1, SR1: 2, SR2: 3}},
+		},
+		{
+			name: "ADD immediate mode, negative imm5 sign-extends",
+			word: OpAdd<<12 | 1<<9 | 2<<6 | 1<<5 | 0x1f,
+			want: Inst{Op: OpAdd, Args: Args{DR: 1, SR1: 2, ImmFlag: true, Imm5: -1}},
+		},
+		{
+			name: "LD, negative PCOffset9 sign-extends",
+			word: OpLoad<<12 | 3<<9 | 0x1ff,
+			want: Inst{Op: OpLoad, Args: Args{DR: 3, PCOffset9: -1}},
+		},
+		{
+			name: "LDR decodes the base register into SR1",
+			word: OpLoadRegister<<12 | 1<<9 | 4<<6 | 0x3e,
+			want: Inst{Op: OpLoadRegister, Args: Args{DR: 1, SR1: 4, Offset6: -2}},
+		},
+		{
+			name: "STR decodes the base register into SR1",
+			word: OpStoreRegister<<12 | 1<<9 | 4<<6 | 2,
+			want: Inst{Op: OpStoreRegister, Args: Args{DR: 1, SR1: 4, Offset6: 2}},
+		},
+		{
+			name: "JSR sets Long and sign-extends PCOffset11",
+			word: OpJumpRegister<<12 | 1<<11 | 0x7ff,
+			want: Inst{Op: OpJumpRegister, Args: Args{Long: true, PCOffset11: -1}},
+		},
+		{
+			name: "JSRR clears Long and decodes the target register",
+			word: OpJumpRegister<<12 | 5<<6,
+			want: Inst{Op: OpJumpRegister, Args: Args{SR1: 5}},
+		},
+		{
+			name: "BRnzp sets all three condition flags",
+			word: OpBreak<<12 | 0x7<<9 | 0x1fe,
+			want: Inst{Op: OpBreak, Args: Args{CondN: true, CondZ: true, CondP: true, PCOffset9: -2}},
+		},
+		{
+			name: "BRz sets only the zero flag",
+			word: OpBreak<<12 | 1<<10 | 5,
+			want: Inst{Op: OpBreak, Args: Args{CondZ: true, PCOffset9: 5}},
+		},
+		{
+			name: "TRAP decodes the 8-bit vector",
+			word: OpTrap<<12 | TrapHalt,
+			want: Inst{Op: OpTrap, Args: Args{TrapVec: TrapHalt}},
+		},
+		{
+			name: "RTI has no operand fields",
+			word: OpRti << 12,
+			want: Inst{Op: OpRti},
+		},
+		{
+			name: "the reserved opcode decodes to OpRes",
+			word: OpRes << 12,
+			want: Inst{Op: OpRes},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := decode(c.word)
+			if got != c.want {
+				t.Errorf("decode(%#04x) = %+v, want %+v", c.word, got, c.want)
+			}
+		})
+	}
+}
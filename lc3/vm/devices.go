@@ -0,0 +1,169 @@
+package vm
+
+// Memory-mapped device registers. MrKbsr/MrKbdr are part of the base LC-3
+// ISA; MrDsr/MrDdr and MrMcr match the addresses the ISA's reference
+// simulator uses. MrTimerCtrl/MrTimerInterval are this package's own
+// extension — the base ISA doesn't define a timer.
+const (
+	MrKbsr          = 0xFE00 /* keyboard status */
+	MrKbdr          = 0xFE02 /* keyboard data */
+	MrDsr           = 0xFE04 /* display status */
+	MrDdr           = 0xFE06 /* display data */
+	MrMcr           = 0xFFFE /* machine control */
+	MrTimerCtrl     = 0xFE10 /* timer control: bit 15 enables, write resets and (re)arms */
+	MrTimerInterval = 0xFE12 /* timer interval, in instructions */
+)
+
+// VectorTimer is the interrupt vector the Timer device raises, processed at
+// the next instruction boundary. Like VectorIllegalOpcode in vm.go, the
+// base LC-3 ISA doesn't assign one, so this package picks x02.
+const VectorTimer = 0x02
+
+// RAM is the bus's backing store: every address in the space reads back
+// whatever was last written to it, unless another attached device claims
+// that address first.
+type RAM struct {
+	mem [MemSize]uint16
+}
+
+func (r *RAM) Claims(addr uint16) bool { return true }
+func (r *RAM) Read(addr uint16) uint16 { return r.mem[addr] }
+func (r *RAM) Write(addr uint16, val uint16) {
+	r.mem[addr] = val
+}
+
+// Keyboard backs MrKbsr/MrKbdr: reading the status register polls IO and,
+// if a key is ready, latches it into the data register and reports ready;
+// reading the data register returns whatever was last latched.
+type Keyboard struct {
+	io   IO
+	kbdr uint16
+}
+
+// NewKeyboard returns a keyboard device driven by io.
+func NewKeyboard(io IO) *Keyboard {
+	return &Keyboard{io: io}
+}
+
+func (k *Keyboard) Claims(addr uint16) bool { return addr == MrKbsr || addr == MrKbdr }
+
+func (k *Keyboard) Read(addr uint16) uint16 {
+	if addr == MrKbsr {
+		if k.io.CheckKey() {
+			k.kbdr = k.io.GetChar()
+			return 1 << 15
+		}
+		return 0
+	}
+	return k.kbdr
+}
+
+func (k *Keyboard) Write(addr uint16, val uint16) {
+	// Real hardware ignores writes to these registers; so does this one.
+}
+
+// Display backs MrDsr/MrDdr: it reports always-ready (this package's IO
+// writes synchronously) and forwards data-register writes to IO.Out.
+type Display struct {
+	io IO
+}
+
+// NewDisplay returns a display device driven by io.
+func NewDisplay(io IO) *Display {
+	return &Display{io: io}
+}
+
+func (d *Display) Claims(addr uint16) bool { return addr == MrDsr || addr == MrDdr }
+
+func (d *Display) Read(addr uint16) uint16 {
+	if addr == MrDsr {
+		return 1 << 15
+	}
+	return 0
+}
+
+func (d *Display) Write(addr uint16, val uint16) {
+	if addr == MrDdr {
+		d.io.Out(byte(val))
+	}
+}
+
+// MCR backs the machine control register: clearing its top bit halts the
+// machine cleanly, the same way a real LC-3's clock-enable bit does,
+// instead of the VM needing to special-case a HALT trap at the bus level.
+type MCR struct {
+	value uint16
+	halt  func()
+}
+
+// NewMCR returns an MCR device that calls halt when the machine is
+// switched off.
+func NewMCR(halt func()) *MCR {
+	return &MCR{value: 1 << 15, halt: halt}
+}
+
+func (m *MCR) Claims(addr uint16) bool { return addr == MrMcr }
+func (m *MCR) Read(addr uint16) uint16 { return m.value }
+func (m *MCR) Write(addr uint16, val uint16) {
+	m.value = val
+	if m.value&(1<<15) == 0 {
+		m.halt()
+	}
+}
+
+// Timer backs MrTimerCtrl/MrTimerInterval: writing a nonzero interval and
+// setting the control register's enable bit arms it to call interrupt
+// every `interval` instructions once Tick is driven from the VM's
+// instruction loop, reloading itself automatically (a one-shot is a handler
+// that clears the control register's enable bit).
+type Timer struct {
+	interrupt func()
+	interval  uint16
+	remaining uint16
+	enabled   bool
+}
+
+// NewTimer returns a timer device that calls interrupt when it fires.
+func NewTimer(interrupt func()) *Timer {
+	return &Timer{interrupt: interrupt}
+}
+
+func (t *Timer) Claims(addr uint16) bool { return addr == MrTimerCtrl || addr == MrTimerInterval }
+
+func (t *Timer) Read(addr uint16) uint16 {
+	if addr == MrTimerCtrl {
+		if t.enabled {
+			return 1 << 15
+		}
+		return 0
+	}
+	return t.interval
+}
+
+func (t *Timer) Write(addr uint16, val uint16) {
+	switch addr {
+	case MrTimerCtrl:
+		t.enabled = val&(1<<15) != 0
+		if t.enabled {
+			t.remaining = t.interval
+		}
+	case MrTimerInterval:
+		t.interval = val
+	}
+}
+
+// Tick decrements the countdown by one instruction and fires the
+// interrupt (reloading from interval) when it reaches zero. The VM calls
+// this once per executed instruction.
+func (t *Timer) Tick() {
+	if !t.enabled || t.interval == 0 {
+		return
+	}
+
+	if t.remaining <= 1 {
+		t.remaining = t.interval
+		t.interrupt()
+		return
+	}
+	t.remaining--
+}
@@ -0,0 +1,106 @@
+package vm
+
+import "testing"
+
+func TestSystemBusDispatchesToMostRecentlyAttachedDevice(t *testing.T) {
+	bus := NewSystemBus()
+	bus.Write(0x1234, 0xBEEF)
+	if got := bus.Read(0x1234); got != 0xBEEF {
+		t.Fatalf("RAM read = %#04x, want 0xbeef", got)
+	}
+
+	io := &testIO{}
+	bus.Attach(NewDisplay(io))
+	bus.Write(MrDdr, 'x')
+	if len(io.out) != 1 || io.out[0] != 'x' {
+		t.Fatalf("io.out = %v, want [x]", io.out)
+	}
+	// An address the display doesn't claim still falls through to RAM.
+	if got := bus.Read(0x1234); got != 0xBEEF {
+		t.Fatalf("RAM read after attaching a device = %#04x, want 0xbeef", got)
+	}
+}
+
+type keyIO struct {
+	testIO
+	pending bool
+	key     uint16
+}
+
+func (k *keyIO) CheckKey() bool  { return k.pending }
+func (k *keyIO) GetChar() uint16 { k.pending = false; return k.key }
+
+func TestKeyboardLatchesOnlyWhenAKeyIsReady(t *testing.T) {
+	io := &keyIO{}
+	kb := NewKeyboard(io)
+
+	if got := kb.Read(MrKbsr); got != 0 {
+		t.Fatalf("MrKbsr = %#04x with no key pending, want 0", got)
+	}
+
+	io.pending, io.key = true, 'a'
+	if got := kb.Read(MrKbsr); got != 1<<15 {
+		t.Fatalf("MrKbsr = %#04x with a key pending, want ready bit set", got)
+	}
+	if got := kb.Read(MrKbdr); got != 'a' {
+		t.Fatalf("MrKbdr = %c, want 'a'", got)
+	}
+	// Re-reading MRKBDR after the key is consumed returns the latched value.
+	if got := kb.Read(MrKbdr); got != 'a' {
+		t.Fatalf("MrKbdr on re-read = %c, want the still-latched 'a'", got)
+	}
+}
+
+func TestMCRHaltsOnClockDisable(t *testing.T) {
+	halted := false
+	mcr := NewMCR(func() { halted = true })
+
+	mcr.Write(MrMcr, mcr.Read(MrMcr)) // rewriting the enabled value is a no-op
+	if halted {
+		t.Fatal("halt called without the clock-enable bit being cleared")
+	}
+
+	mcr.Write(MrMcr, 0)
+	if !halted {
+		t.Fatal("halt not called after clearing the clock-enable bit")
+	}
+}
+
+func TestTimerFiresEveryIntervalTicksAndReloads(t *testing.T) {
+	fires := 0
+	timer := NewTimer(func() { fires++ })
+	timer.Write(MrTimerInterval, 2)
+	timer.Write(MrTimerCtrl, 1<<15)
+
+	for i := 0; i < 1; i++ {
+		timer.Tick()
+		if fires != 0 {
+			t.Fatalf("fired after %d tick(s), want 2", i+1)
+		}
+	}
+	timer.Tick()
+	if fires != 1 {
+		t.Fatalf("fires = %d after 2 ticks, want 1", fires)
+	}
+
+	for i := 0; i < 1; i++ {
+		timer.Tick()
+	}
+	timer.Tick()
+	if fires != 2 {
+		t.Fatalf("fires = %d after a second full interval, want 2", fires)
+	}
+}
+
+func TestTimerDoesNothingWhenDisabled(t *testing.T) {
+	fires := 0
+	timer := NewTimer(func() { fires++ })
+	timer.Write(MrTimerInterval, 1)
+
+	for i := 0; i < 5; i++ {
+		timer.Tick()
+	}
+	if fires != 0 {
+		t.Fatalf("fires = %d while disabled, want 0", fires)
+	}
+}
@@ -0,0 +1,268 @@
+package vm
+
+// StopReason says why Run or StepInstruction returned control to the
+// caller, so a debugger front end (lc3/debug's GDB stub, or any other) can
+// translate it into the right stop reply.
+type StopReason int
+
+const (
+	StopHalt       StopReason = iota // the machine halted (a HALT trap, or the MCR clock-enable bit cleared)
+	StopBreakpoint                   // execution reached a set breakpoint
+	StopSignal                       // stop was requested externally (Run's stop channel)
+	StopStep                         // a single instruction completed (StepInstruction)
+)
+
+// Run executes instructions starting at the current program counter until a
+// HALT trap, a breakpoint, or stop being closed returns control. stop may
+// be nil, in which case only HALT or a breakpoint stop it.
+//
+// A breakpoint at the current PC is checked before fetching that
+// instruction, so resuming from a breakpoint that's still set requires
+// stepping over it first (see lc3/debug, which does this for GDB's
+// "continue").
+func (v *VM) Run(stop <-chan struct{}) StopReason {
+	v.Running = true
+
+	for v.Running {
+		if stop != nil {
+			select {
+			case <-stop:
+				return StopSignal
+			default:
+			}
+		}
+
+		if v.HasBreakpoint(v.Registers[RPc]) {
+			return StopBreakpoint
+		}
+
+		v.step()
+	}
+
+	return StopHalt
+}
+
+// StepInstruction executes exactly one instruction, ignoring any breakpoint
+// at the current PC, and reports whether it halted the machine.
+func (v *VM) StepInstruction() StopReason {
+	v.Running = true
+	v.step()
+	if !v.Running {
+		return StopHalt
+	}
+	return StopStep
+}
+
+// step fetches, decodes, and executes a single instruction, then ticks the
+// timer and services any interrupt it (or another RaiseInterrupt caller)
+// raised — interrupts are only taken at this instruction boundary, never
+// mid-instruction.
+func (v *VM) step() {
+	v.interruptServiced = false
+
+	word := v.memRead(v.Registers[RPc])
+	v.Registers[RPc]++
+
+	inst := decode(word)
+	v.execute(inst)
+
+	if v.Timer != nil {
+		v.Timer.Tick()
+	}
+
+	if v.pendingInterrupt != nil {
+		vector := *v.pendingInterrupt
+		v.pendingInterrupt = nil
+		v.interruptServiced = true
+		v.raiseException(vector)
+	}
+}
+
+// execute dispatches a decoded instruction to its semantics. Adding a new
+// opcode means adding a row to decodeTable and a case here, rather than
+// reworking a single monolithic switch.
+func (v *VM) execute(inst Inst) {
+	a := inst.Args
+
+	switch inst.Op {
+	case OpBreak:
+		isSet := (a.CondN && v.Registers[RCond] == FlagNegative) ||
+			(a.CondZ && v.Registers[RCond] == FlagZero) ||
+			(a.CondP && v.Registers[RCond] == FlagPositive)
+
+		if isSet {
+			v.Registers[RPc] = v.Registers[RPc] + uint16(a.PCOffset9)
+		}
+
+	case OpAdd:
+		if a.ImmFlag {
+			v.Registers[a.DR] = v.Registers[a.SR1] + uint16(a.Imm5)
+		} else {
+			v.Registers[a.DR] = v.Registers[a.SR1] + v.Registers[a.SR2]
+		}
+		v.updateFlags(a.DR)
+
+	case OpLoad:
+		v.Registers[a.DR] = v.memRead(v.Registers[RPc] + uint16(a.PCOffset9))
+		v.updateFlags(a.DR)
+
+	case OpStore:
+		v.memWrite(v.Registers[RPc]+uint16(a.PCOffset9), v.Registers[a.DR])
+
+	case OpJumpRegister:
+		v.Registers[R7] = v.Registers[RPc]
+
+		if a.Long {
+			v.Registers[RPc] = v.Registers[RPc] + uint16(a.PCOffset11)
+		} else {
+			v.Registers[RPc] = v.Registers[a.SR1]
+		}
+
+	case OpAnd:
+		if a.ImmFlag {
+			v.Registers[a.DR] = v.Registers[a.SR1] & uint16(a.Imm5)
+		} else {
+			v.Registers[a.DR] = v.Registers[a.SR1] & v.Registers[a.SR2]
+		}
+		v.updateFlags(a.DR)
+
+	case OpLoadRegister:
+		v.Registers[a.DR] = v.memRead(v.Registers[a.SR1] + uint16(a.Offset6))
+		v.updateFlags(a.DR)
+
+	case OpStoreRegister:
+		v.memWrite(v.Registers[a.SR1]+uint16(a.Offset6), v.Registers[a.DR])
+
+	case OpRti:
+		v.execRti()
+
+	case OpNot:
+		v.Registers[a.DR] = ^v.Registers[a.SR1]
+		v.updateFlags(a.DR)
+
+	case OpLoadIndirect:
+		v.Registers[a.DR] = v.memRead(v.memRead(v.Registers[RPc] + uint16(a.PCOffset9)))
+		v.updateFlags(a.DR)
+
+	case OpStoreIndirect:
+		v.memWrite(v.memRead(v.Registers[RPc]+uint16(a.PCOffset9)), v.Registers[a.DR])
+
+	case OpJump:
+		v.Registers[RPc] = v.Registers[a.SR1]
+
+	case OpRes:
+		v.raiseException(VectorIllegalOpcode)
+
+	case OpLoadEffectiveAddress:
+		v.Registers[a.DR] = v.Registers[RPc] + uint16(a.PCOffset9)
+		v.updateFlags(a.DR)
+
+	case OpTrap:
+		v.trap(a.TrapVec)
+	}
+}
+
+// execRti implements RTI: only legal from supervisor mode, it pops PC and
+// PSR off the supervisor stack and, if that PSR indicates a return to user
+// mode, swaps R6 back to the saved user stack pointer. Executing it from
+// user mode is itself a privilege-mode violation.
+func (v *VM) execRti() {
+	if v.PSR&PsrUserMode != 0 {
+		v.raiseException(VectorPrivilegeViolation)
+		return
+	}
+
+	v.Registers[RPc] = v.memRead(v.Registers[R6])
+	v.Registers[R6]++
+	v.PSR = v.memRead(v.Registers[R6])
+	v.Registers[R6]++
+
+	if v.PSR&PsrUserMode != 0 {
+		v.SavedSSP = v.Registers[R6]
+		v.Registers[R6] = v.SavedUSP
+	}
+}
+
+// raiseException enters supervisor mode to service a trap or exception: it
+// switches to the supervisor stack (saving the user one, if coming from
+// user mode), pushes the interrupted PSR and PC so RTI can resume the
+// interrupted instruction stream, and transfers control through vector.
+func (v *VM) raiseException(vector uint16) {
+	if v.PSR&PsrUserMode != 0 {
+		v.SavedUSP = v.Registers[R6]
+		v.Registers[R6] = v.SavedSSP
+	}
+
+	v.Registers[R6]--
+	v.memWrite(v.Registers[R6], v.PSR)
+	v.Registers[R6]--
+	v.memWrite(v.Registers[R6], v.Registers[RPc])
+
+	v.PSR &^= PsrUserMode
+	v.Registers[RPc] = v.memRead(vector)
+}
+
+func (v *VM) trap(trapCode uint16) {
+	switch trapCode {
+	case TrapGetC:
+
+		v.Registers[R0] = v.IO.GetChar()
+
+	case TrapOut:
+
+		v.IO.Out(byte(v.Registers[R0] & 0xff))
+
+	case TrapPutS:
+
+		loc := v.Registers[R0]
+
+		for {
+			char := v.memRead(loc)
+
+			if char == 0 {
+				break
+			}
+
+			v.IO.Out(byte(char))
+			loc++
+		}
+
+	case TrapIn:
+
+		for _, c := range "Enter a character: " {
+			v.IO.Out(byte(c))
+		}
+
+		v.Registers[R0] = v.IO.GetChar()
+
+	case TrapPutSP:
+
+		loc := v.Registers[R0]
+
+		for {
+
+			read := v.memRead(loc)
+			first := read & 0xff
+
+			if first == 0 {
+				break
+			}
+
+			v.IO.Out(byte(first))
+
+			second := read >> 8
+
+			if second == 0 {
+				break
+			}
+
+			v.IO.Out(byte(second))
+
+			loc++
+		}
+
+	case TrapHalt:
+
+		v.Running = false
+	}
+}
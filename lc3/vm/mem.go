@@ -0,0 +1,80 @@
+package vm
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// memRead and memWrite are the single chokepoint every instruction's memory
+// access goes through: they dispatch to the bus, then notify whatever
+// instrumentation (StepVM's access log, breakpoint/watchpoint checks) is
+// currently installed.
+func (v *VM) memRead(address uint16) uint16 {
+	val := v.Bus.Read(address)
+
+	if v.accessHook != nil {
+		v.accessHook(address, false)
+	}
+	v.checkWatchpoints(address, false, val)
+
+	return val
+}
+
+func (v *VM) memWrite(address uint16, value uint16) {
+	if v.accessHook != nil {
+		v.accessHook(address, true)
+	}
+	v.checkWatchpoints(address, true, value)
+	v.Bus.Write(address, value)
+}
+
+// LoadImageFile reads either a raw big-endian LC-3 object file (an origin
+// word followed by the program image) or a tagged LC3S snapshot produced
+// by Snapshot, detected by its magic header. A snapshot restores the full
+// VM state it was taken from — registers, PSR, and all — not just memory.
+func (v *VM) LoadImageFile(r io.Reader) error {
+
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(len(snapshotMagic)); err == nil && bytes.Equal(magic, snapshotMagic[:]) {
+		return v.loadSnapshot(br)
+	}
+
+	origin, err := readUint16(br)
+	if err != nil {
+		return err
+	}
+
+	for addr := int(origin); addr < MemSize; addr++ {
+		val, err := readUint16(br)
+
+		if err != nil && err == io.EOF {
+			break
+		}
+
+		v.Bus.Write(uint16(addr), val)
+	}
+
+	return nil
+}
+
+func readUint16(r io.Reader) (uint16, error) {
+
+	value := make([]byte, 2)
+	_, err := r.Read(value[:])
+
+	if err != nil {
+		return 0, err
+	}
+
+	reader := bytes.NewReader(value[:])
+
+	var finalVal uint16
+	err = binary.Read(reader, binary.BigEndian, &finalVal)
+	if err != nil {
+		return 0, err
+	}
+
+	return finalVal, nil
+}
@@ -0,0 +1,206 @@
+package vm
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// PageWords is the page size used for Merkle-hashing memory: 4 KiB pages
+// (2 bytes per LC-3 word) over the 128 KiB address space.
+const PageWords = 4096 / 2
+
+// NumPages is how many PageWords-sized pages cover the address space,
+// rounding the last page up if MemSize isn't an exact multiple.
+const NumPages = (MemSize + PageWords - 1) / PageWords
+
+// Hash is a 32-byte Merkle node or leaf digest.
+type Hash = [32]byte
+
+// pageHash hashes one page's words, big-endian, as a leaf of the memory
+// Merkle tree.
+func pageHash(words []uint16) Hash {
+	buf := make([]byte, len(words)*2)
+	for i, w := range words {
+		binary.BigEndian.PutUint16(buf[i*2:], w)
+	}
+	return sha256.Sum256(buf)
+}
+
+// registersHash hashes the register file as the tree's final leaf.
+func registersHash(regs [RCount]uint16) Hash {
+	buf := make([]byte, RCount*2)
+	for i, r := range regs {
+		binary.BigEndian.PutUint16(buf[i*2:], r)
+	}
+	return sha256.Sum256(buf)
+}
+
+// leaves returns the ordered Merkle leaves for a machine state: one hash
+// per memory page, followed by one hash for the register file.
+func leaves(mem [MemSize]uint16, regs [RCount]uint16) []Hash {
+	ls := make([]Hash, 0, NumPages+1)
+
+	for p := 0; p < NumPages; p++ {
+		start := p * PageWords
+		end := start + PageWords
+		if end > MemSize {
+			end = MemSize
+		}
+		ls = append(ls, pageHash(mem[start:end]))
+	}
+
+	return append(ls, registersHash(regs))
+}
+
+// merkleNode combines two child hashes into their parent.
+func merkleNode(left, right Hash) Hash {
+	var buf [64]byte
+	copy(buf[:32], left[:])
+	copy(buf[32:], right[:])
+	return sha256.Sum256(buf[:])
+}
+
+// merkleRoot builds a binary Merkle tree over leaves, padding with
+// zero-value hashes up to the next power of two, and returns its root.
+func merkleRoot(ls []Hash) Hash {
+	level := padLeaves(ls)
+	for len(level) > 1 {
+		next := make([]Hash, len(level)/2)
+		for i := range next {
+			next[i] = merkleNode(level[2*i], level[2*i+1])
+		}
+		level = next
+	}
+	return level[0]
+}
+
+// merkleProof returns the sibling hashes on the path from leaf index to the
+// root, in bottom-up order, so a verifier can recompute the root from the
+// leaf alone.
+func merkleProof(ls []Hash, index int) []Hash {
+	level := padLeaves(ls)
+	var proof []Hash
+
+	for len(level) > 1 {
+		sibling := index ^ 1
+		proof = append(proof, level[sibling])
+
+		next := make([]Hash, len(level)/2)
+		for i := range next {
+			next[i] = merkleNode(level[2*i], level[2*i+1])
+		}
+		level = next
+		index /= 2
+	}
+
+	return proof
+}
+
+// verifyMerkleProof recomputes the root from a leaf and its proof, for use
+// by an external verifier replaying a StepWitness.
+func verifyMerkleProof(leaf Hash, index int, proof []Hash, root Hash) bool {
+	h := leaf
+	for _, sibling := range proof {
+		if index%2 == 0 {
+			h = merkleNode(h, sibling)
+		} else {
+			h = merkleNode(sibling, h)
+		}
+		index /= 2
+	}
+	return h == root
+}
+
+func padLeaves(ls []Hash) []Hash {
+	size := 1
+	for size < len(ls) {
+		size *= 2
+	}
+	padded := make([]Hash, size)
+	copy(padded, ls)
+	return padded
+}
+
+// merkleDepth returns how many levels separate a padLeaves-sized tree's
+// leaves from its root for a tree originally built over n leaves — the
+// length of every MerkleProof.Siblings/merkleProof result.
+func merkleDepth(n int) int {
+	size, depth := 1, 0
+	for size < n {
+		size *= 2
+		depth++
+	}
+	return depth
+}
+
+// combinedRoot recomputes a Merkle root after some of its leaves change,
+// given each changed leaf's new hash and its pre-state sibling path
+// (as returned by merkleProof against the old root). It's how
+// StepWitness.Verify derives PostStateRoot from RegsAfter and the written
+// pages without being handed the rest of memory.
+//
+// A naive per-leaf fold (new leaf + old siblings) breaks when two changed
+// leaves' paths merge before the root: at the level they merge, each
+// one's recorded sibling is the *other* leaf's now-stale pre-state value.
+// This walks level by level instead, combining two changed leaves
+// directly with each other once both are known and only falling back to
+// a stored sibling when the other side of a pair didn't change.
+func combinedRoot(updates map[int]Hash, siblings map[int][]Hash, depth int) Hash {
+	current := make(map[int]Hash, len(updates))
+	for idx, h := range updates {
+		current[idx] = h
+	}
+	owners := make(map[int][]Hash, len(siblings))
+	for idx, s := range siblings {
+		owners[idx] = s
+	}
+
+	for level := 0; level < depth; level++ {
+		next := make(map[int]Hash, len(current))
+		nextOwners := make(map[int][]Hash, len(current))
+		handled := make(map[int]bool, len(current))
+
+		for idx, h := range current {
+			if handled[idx] {
+				continue
+			}
+			handled[idx] = true
+
+			var combined Hash
+			if sibH, ok := current[idx^1]; ok {
+				handled[idx^1] = true
+				if idx%2 == 0 {
+					combined = merkleNode(h, sibH)
+				} else {
+					combined = merkleNode(sibH, h)
+				}
+			} else {
+				sib := owners[idx][level]
+				if idx%2 == 0 {
+					combined = merkleNode(h, sib)
+				} else {
+					combined = merkleNode(sib, h)
+				}
+			}
+
+			parent := idx / 2
+			next[parent] = combined
+			if _, ok := nextOwners[parent]; !ok {
+				nextOwners[parent] = owners[idx]
+			}
+		}
+
+		current, owners = next, nextOwners
+	}
+
+	return current[0]
+}
+
+// StateHash computes the Merkle root over the VM's memory pages and
+// register file. An external verifier replaying a StepWitness checks this
+// against the witness's PreStateRoot/PostStateRoot. The memory half covers
+// RAM only: transient MMIO registers (a keyboard's latched key, a timer's
+// countdown) aren't part of the hashed state.
+func (v *VM) StateHash() Hash {
+	return merkleRoot(leaves(snapshotMemory(v.Bus), v.Registers))
+}
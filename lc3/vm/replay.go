@@ -0,0 +1,199 @@
+package vm
+
+import "fmt"
+
+// replayState is a pure, side-effect-free stand-in for a VM's
+// architectural state during StepWitness verification: it executes
+// exactly one decoded instruction against a verifier-supplied register
+// file and a sparse memory map built entirely from Merkle-proven
+// MemAccess.Before values, with no Bus, IO, or Timer involved.
+type replayState struct {
+	regs     [RCount]uint16
+	psr      uint16
+	savedSSP uint16
+	savedUSP uint16
+	mem      map[uint16]uint16
+	writes   map[uint16]uint16
+	err      error
+}
+
+// memRead returns the proven Before value at addr, recording an error if
+// the instruction reads an address the witness never proved anything
+// about — a witness that's missing a required MemAccess can't be
+// replayed, so Verify treats that as a failure rather than guessing.
+func (r *replayState) memRead(addr uint16) uint16 {
+	v, ok := r.mem[addr]
+	if !ok && r.err == nil {
+		r.err = fmt.Errorf("lc3: replay read x%04x has no proven Before value", addr)
+	}
+	return v
+}
+
+func (r *replayState) memWrite(addr, val uint16) {
+	r.mem[addr] = val
+	r.writes[addr] = val
+}
+
+func (r *replayState) updateFlags(reg uint16) {
+	switch {
+	case r.regs[reg] == 0:
+		r.regs[RCond] = FlagZero
+	case r.regs[reg]>>15 == 1:
+		r.regs[RCond] = FlagNegative
+	default:
+		r.regs[RCond] = FlagPositive
+	}
+}
+
+// execute replays inst's register/memory semantics against r, mirroring
+// VM.execute exactly except for trap vectors with external side effects
+// (see trap) and for Running, which isn't part of the hashed state.
+func (r *replayState) execute(inst Inst) {
+	a := inst.Args
+
+	switch inst.Op {
+	case OpBreak:
+		isSet := (a.CondN && r.regs[RCond] == FlagNegative) ||
+			(a.CondZ && r.regs[RCond] == FlagZero) ||
+			(a.CondP && r.regs[RCond] == FlagPositive)
+
+		if isSet {
+			r.regs[RPc] = r.regs[RPc] + uint16(a.PCOffset9)
+		}
+
+	case OpAdd:
+		if a.ImmFlag {
+			r.regs[a.DR] = r.regs[a.SR1] + uint16(a.Imm5)
+		} else {
+			r.regs[a.DR] = r.regs[a.SR1] + r.regs[a.SR2]
+		}
+		r.updateFlags(a.DR)
+
+	case OpLoad:
+		r.regs[a.DR] = r.memRead(r.regs[RPc] + uint16(a.PCOffset9))
+		r.updateFlags(a.DR)
+
+	case OpStore:
+		r.memWrite(r.regs[RPc]+uint16(a.PCOffset9), r.regs[a.DR])
+
+	case OpJumpRegister:
+		r.regs[R7] = r.regs[RPc]
+
+		if a.Long {
+			r.regs[RPc] = r.regs[RPc] + uint16(a.PCOffset11)
+		} else {
+			r.regs[RPc] = r.regs[a.SR1]
+		}
+
+	case OpAnd:
+		if a.ImmFlag {
+			r.regs[a.DR] = r.regs[a.SR1] & uint16(a.Imm5)
+		} else {
+			r.regs[a.DR] = r.regs[a.SR1] & r.regs[a.SR2]
+		}
+		r.updateFlags(a.DR)
+
+	case OpLoadRegister:
+		r.regs[a.DR] = r.memRead(r.regs[a.SR1] + uint16(a.Offset6))
+		r.updateFlags(a.DR)
+
+	case OpStoreRegister:
+		r.memWrite(r.regs[a.SR1]+uint16(a.Offset6), r.regs[a.DR])
+
+	case OpRti:
+		r.execRti()
+
+	case OpNot:
+		r.regs[a.DR] = ^r.regs[a.SR1]
+		r.updateFlags(a.DR)
+
+	case OpLoadIndirect:
+		r.regs[a.DR] = r.memRead(r.memRead(r.regs[RPc] + uint16(a.PCOffset9)))
+		r.updateFlags(a.DR)
+
+	case OpStoreIndirect:
+		r.memWrite(r.memRead(r.regs[RPc]+uint16(a.PCOffset9)), r.regs[a.DR])
+
+	case OpJump:
+		r.regs[RPc] = r.regs[a.SR1]
+
+	case OpRes:
+		r.raiseException(VectorIllegalOpcode)
+
+	case OpLoadEffectiveAddress:
+		r.regs[a.DR] = r.regs[RPc] + uint16(a.PCOffset9)
+		r.updateFlags(a.DR)
+
+	case OpTrap:
+		r.trap(a.TrapVec)
+	}
+}
+
+// execRti mirrors VM.execRti.
+func (r *replayState) execRti() {
+	if r.psr&PsrUserMode != 0 {
+		r.raiseException(VectorPrivilegeViolation)
+		return
+	}
+
+	r.regs[RPc] = r.memRead(r.regs[R6])
+	r.regs[R6]++
+	r.psr = r.memRead(r.regs[R6])
+	r.regs[R6]++
+
+	if r.psr&PsrUserMode != 0 {
+		r.savedSSP = r.regs[R6]
+		r.regs[R6] = r.savedUSP
+	}
+}
+
+// raiseException mirrors VM.raiseException.
+func (r *replayState) raiseException(vector uint16) {
+	if r.psr&PsrUserMode != 0 {
+		r.savedUSP = r.regs[R6]
+		r.regs[R6] = r.savedSSP
+	}
+
+	r.regs[R6]--
+	r.memWrite(r.regs[R6], r.psr)
+	r.regs[R6]--
+	r.memWrite(r.regs[R6], r.regs[RPc])
+
+	r.psr &^= PsrUserMode
+	r.regs[RPc] = r.memRead(vector)
+}
+
+// trap replays the deterministic, memory-visible half of VM.trap. GETC
+// and IN pull a character from an external keyboard the witness can't
+// reproduce, so they're left for the caller to special-case against
+// RegsAfter rather than replayed here; OUT and HALT have no register or
+// memory effect at all (IO.Out and Running aren't part of the hashed
+// state). PUTS/PUTSP only read memory, which replays exactly like
+// VM.trap.
+func (r *replayState) trap(trapCode uint16) {
+	switch trapCode {
+	case TrapPutS:
+		loc := r.regs[R0]
+		for {
+			char := r.memRead(loc)
+			if char == 0 {
+				break
+			}
+			loc++
+		}
+
+	case TrapPutSP:
+		loc := r.regs[R0]
+		for {
+			read := r.memRead(loc)
+			first := read & 0xff
+			if first == 0 {
+				break
+			}
+			if read>>8 == 0 {
+				break
+			}
+			loc++
+		}
+	}
+}
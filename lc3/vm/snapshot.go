@@ -0,0 +1,273 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// snapshotMagic identifies the tagged snapshot container. A raw LC-3
+// object file's first bytes are an origin address followed immediately by
+// program words, so this can only collide with one that happens to load
+// at x4C43 and start with the word x3353 — not worth guarding against
+// further.
+var snapshotMagic = [4]byte{'L', 'C', '3', 'S'}
+
+// snapshotVersion is bumped whenever the payload layout below changes.
+// Restore and LoadImageFile reject any other version with a clear error
+// rather than misparsing it.
+const snapshotVersion = 1
+
+// snapshotEndianness records the byte order memory and registers are
+// encoded in. This package always writes big-endian, matching the raw
+// object format; the byte exists so a future version could vary it
+// instead of silently assuming.
+const snapshotEndianness = 0
+
+// Snapshot writes v's full architectural state — registers, PSR, saved
+// stack pointers, pending interrupt, memory, and symbol table — to w in
+// the tagged LC3S container format, so it can be resumed bit-identically
+// with Restore. Memory is RLE-encoded: LC-3 images are mostly zero-filled,
+// so this is typically far smaller than the raw 128 KiB image.
+func (v *VM) Snapshot(w io.Writer) error {
+	var payload bytes.Buffer
+
+	if err := binary.Write(&payload, binary.BigEndian, v.Registers); err != nil {
+		return err
+	}
+	if err := binary.Write(&payload, binary.BigEndian, v.PSR); err != nil {
+		return err
+	}
+	if err := binary.Write(&payload, binary.BigEndian, v.SavedSSP); err != nil {
+		return err
+	}
+	if err := binary.Write(&payload, binary.BigEndian, v.SavedUSP); err != nil {
+		return err
+	}
+
+	if v.pendingInterrupt != nil {
+		payload.WriteByte(1)
+		if err := binary.Write(&payload, binary.BigEndian, *v.pendingInterrupt); err != nil {
+			return err
+		}
+	} else {
+		payload.WriteByte(0)
+	}
+
+	writeRLEMemory(&payload, snapshotMemory(v.Bus))
+	writeSymbols(&payload, v.Symbols)
+
+	if _, err := w.Write(snapshotMagic[:]); err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte{snapshotVersion, snapshotEndianness}); err != nil {
+		return err
+	}
+
+	var crcBuf [4]byte
+	binary.BigEndian.PutUint32(crcBuf[:], crc32.ChecksumIEEE(payload.Bytes()))
+	if _, err := w.Write(crcBuf[:]); err != nil {
+		return err
+	}
+
+	_, err := w.Write(payload.Bytes())
+	return err
+}
+
+// Restore rebuilds a VM from a snapshot previously written by Snapshot.
+// It deliberately takes an IO binding rather than being a bare
+// func(io.Reader) (*VM, error): a device's IO binding (the keyboard/display
+// driving it) isn't part of the serialized state, so something has to
+// supply one, the same way NewDefault's caller does for a fresh VM.
+func Restore(r io.Reader, io IO) (*VM, error) {
+	v := NewDefault(io)
+	if err := v.loadSnapshot(r); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// loadSnapshot reads a tagged LC3S container from r and applies it to v,
+// overwriting its registers, PSR, saved stack pointers, pending interrupt,
+// memory, and symbol table in place.
+func (v *VM) loadSnapshot(r io.Reader) error {
+	var header [10]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return fmt.Errorf("lc3: reading snapshot header: %w", err)
+	}
+
+	if !bytes.Equal(header[:4], snapshotMagic[:]) {
+		return fmt.Errorf("lc3: not an LC3S snapshot")
+	}
+	version, endianness := header[4], header[5]
+	if version != snapshotVersion {
+		return fmt.Errorf("lc3: unsupported snapshot version %d (want %d)", version, snapshotVersion)
+	}
+	if endianness != snapshotEndianness {
+		return fmt.Errorf("lc3: unsupported snapshot endianness %d", endianness)
+	}
+	wantCRC := binary.BigEndian.Uint32(header[6:10])
+
+	payload, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("lc3: reading snapshot payload: %w", err)
+	}
+	if got := crc32.ChecksumIEEE(payload); got != wantCRC {
+		return fmt.Errorf("lc3: snapshot CRC mismatch (got %08x, want %08x)", got, wantCRC)
+	}
+
+	body := bytes.NewReader(payload)
+
+	var registers [RCount]uint16
+	if err := binary.Read(body, binary.BigEndian, &registers); err != nil {
+		return fmt.Errorf("lc3: reading snapshot registers: %w", err)
+	}
+	var psr, savedSSP, savedUSP uint16
+	if err := binary.Read(body, binary.BigEndian, &psr); err != nil {
+		return fmt.Errorf("lc3: reading snapshot PSR: %w", err)
+	}
+	if err := binary.Read(body, binary.BigEndian, &savedSSP); err != nil {
+		return fmt.Errorf("lc3: reading snapshot SavedSSP: %w", err)
+	}
+	if err := binary.Read(body, binary.BigEndian, &savedUSP); err != nil {
+		return fmt.Errorf("lc3: reading snapshot SavedUSP: %w", err)
+	}
+
+	pendingFlag, err := body.ReadByte()
+	if err != nil {
+		return fmt.Errorf("lc3: reading snapshot pending-interrupt flag: %w", err)
+	}
+	var pending *uint16
+	if pendingFlag != 0 {
+		var vector uint16
+		if err := binary.Read(body, binary.BigEndian, &vector); err != nil {
+			return fmt.Errorf("lc3: reading snapshot pending-interrupt vector: %w", err)
+		}
+		pending = &vector
+	}
+
+	mem, err := readRLEMemory(body)
+	if err != nil {
+		return fmt.Errorf("lc3: reading snapshot memory: %w", err)
+	}
+	symbols, err := readSymbols(body)
+	if err != nil {
+		return fmt.Errorf("lc3: reading snapshot symbols: %w", err)
+	}
+
+	v.Registers = registers
+	v.PSR = psr
+	v.SavedSSP = savedSSP
+	v.SavedUSP = savedUSP
+	v.pendingInterrupt = pending
+	v.Symbols = symbols
+
+	if s, ok := v.Bus.(interface{ Restore([MemSize]uint16) }); ok {
+		s.Restore(mem)
+	} else {
+		for addr, val := range mem {
+			v.Bus.Write(uint16(addr), val)
+		}
+	}
+
+	return nil
+}
+
+// writeRLEMemory run-length encodes mem as a sequence of (value, run
+// length) pairs: LC-3 images are typically mostly zero, so this is far
+// smaller than the raw 128 KiB image.
+func writeRLEMemory(buf *bytes.Buffer, mem [MemSize]uint16) {
+	var runs [][2]uint32
+	i := 0
+	for i < MemSize {
+		j := i + 1
+		for j < MemSize && mem[j] == mem[i] {
+			j++
+		}
+		runs = append(runs, [2]uint32{uint32(mem[i]), uint32(j - i)})
+		i = j
+	}
+
+	binary.Write(buf, binary.BigEndian, uint32(len(runs)))
+	for _, run := range runs {
+		binary.Write(buf, binary.BigEndian, run[0])
+		binary.Write(buf, binary.BigEndian, run[1])
+	}
+}
+
+// readRLEMemory is the inverse of writeRLEMemory.
+func readRLEMemory(r io.Reader) ([MemSize]uint16, error) {
+	var mem [MemSize]uint16
+
+	var numRuns uint32
+	if err := binary.Read(r, binary.BigEndian, &numRuns); err != nil {
+		return mem, err
+	}
+
+	pos := 0
+	for n := uint32(0); n < numRuns; n++ {
+		var value, length uint32
+		if err := binary.Read(r, binary.BigEndian, &value); err != nil {
+			return mem, err
+		}
+		if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+			return mem, err
+		}
+		if pos+int(length) > MemSize {
+			return mem, fmt.Errorf("run overruns memory: %d words at offset %d", length, pos)
+		}
+		for k := uint32(0); k < length; k++ {
+			mem[pos] = uint16(value)
+			pos++
+		}
+	}
+
+	if pos != MemSize {
+		return mem, fmt.Errorf("runs cover %d words, want %d", pos, MemSize)
+	}
+
+	return mem, nil
+}
+
+// writeSymbols writes an optional label -> address table, as lc3/asm
+// produces, for lc3/disasm to annotate a listing with.
+func writeSymbols(buf *bytes.Buffer, symbols map[string]uint16) {
+	binary.Write(buf, binary.BigEndian, uint32(len(symbols)))
+	for name, addr := range symbols {
+		binary.Write(buf, binary.BigEndian, uint32(len(name)))
+		buf.WriteString(name)
+		binary.Write(buf, binary.BigEndian, addr)
+	}
+}
+
+// readSymbols is the inverse of writeSymbols.
+func readSymbols(r io.Reader) (map[string]uint16, error) {
+	var count uint32
+	if err := binary.Read(r, binary.BigEndian, &count); err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, nil
+	}
+
+	symbols := make(map[string]uint16, count)
+	for i := uint32(0); i < count; i++ {
+		var nameLen uint32
+		if err := binary.Read(r, binary.BigEndian, &nameLen); err != nil {
+			return nil, err
+		}
+		name := make([]byte, nameLen)
+		if _, err := io.ReadFull(r, name); err != nil {
+			return nil, err
+		}
+		var addr uint16
+		if err := binary.Read(r, binary.BigEndian, &addr); err != nil {
+			return nil, err
+		}
+		symbols[string(name)] = addr
+	}
+
+	return symbols, nil
+}
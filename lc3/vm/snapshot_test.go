@@ -0,0 +1,79 @@
+package vm
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSnapshotRestoreRoundTrip(t *testing.T) {
+	v := New(NewSystemBus(), &testIO{})
+	v.Registers[R0] = 0x1234
+	v.Registers[RPc] = 0x3050
+	v.PSR = 0 // supervisor mode
+	v.SavedSSP = 0x2FFF
+	v.SavedUSP = 0x4000
+	v.Bus.Write(0x3000, 0xBEEF)
+	v.Bus.Write(0x3001, 0xBEEF)
+	v.Bus.Write(0x4000, 0xCAFE)
+	v.RaiseInterrupt(VectorTimer)
+	v.Symbols = map[string]uint16{"START": 0x3000}
+
+	var buf bytes.Buffer
+	if err := v.Snapshot(&buf); err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+
+	restored, err := Restore(&buf, &testIO{})
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+
+	if restored.Registers != v.Registers {
+		t.Errorf("Registers = %+v, want %+v", restored.Registers, v.Registers)
+	}
+	if restored.PSR != v.PSR || restored.SavedSSP != v.SavedSSP || restored.SavedUSP != v.SavedUSP {
+		t.Errorf("PSR/SavedSSP/SavedUSP = %#x/%#x/%#x, want %#x/%#x/%#x",
+			restored.PSR, restored.SavedSSP, restored.SavedUSP, v.PSR, v.SavedSSP, v.SavedUSP)
+	}
+	if restored.pendingInterrupt == nil || *restored.pendingInterrupt != VectorTimer {
+		t.Errorf("pendingInterrupt = %v, want %#x", restored.pendingInterrupt, VectorTimer)
+	}
+	for _, addr := range []uint16{0x3000, 0x3001, 0x4000} {
+		if got, want := restored.Bus.Read(addr), v.Bus.Read(addr); got != want {
+			t.Errorf("Bus.Read(%#04x) = %#04x, want %#04x", addr, got, want)
+		}
+	}
+	if restored.Symbols["START"] != 0x3000 {
+		t.Errorf("Symbols[START] = %#04x, want 0x3000", restored.Symbols["START"])
+	}
+}
+
+func TestSnapshotRejectsTruncatedPayload(t *testing.T) {
+	v := New(NewSystemBus(), &testIO{})
+
+	var buf bytes.Buffer
+	if err := v.Snapshot(&buf); err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+
+	truncated := buf.Bytes()[:buf.Len()-4]
+	if _, err := Restore(bytes.NewReader(truncated), &testIO{}); err == nil {
+		t.Fatal("Restore succeeded on a truncated snapshot, want an error")
+	}
+}
+
+func TestSnapshotRejectsCorruptedCRC(t *testing.T) {
+	v := New(NewSystemBus(), &testIO{})
+	v.Registers[R0] = 42
+
+	var buf bytes.Buffer
+	if err := v.Snapshot(&buf); err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+
+	corrupted := buf.Bytes()
+	corrupted[len(corrupted)-1] ^= 0xFF // flip every bit of the payload's last byte
+	if _, err := Restore(bytes.NewReader(corrupted), &testIO{}); err == nil {
+		t.Fatal("Restore succeeded on a snapshot with a corrupted CRC, want an error")
+	}
+}
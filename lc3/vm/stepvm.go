@@ -0,0 +1,268 @@
+package vm
+
+// MemAccess is one memory word read or written during a step, together
+// with a Merkle proof (against the step's PreStateRoot) that its Before
+// value really was at Address in the pre-step state.
+type MemAccess struct {
+	Address uint16
+	Before  uint16
+	After   uint16
+	Write   bool
+	Proof   MerkleProof
+}
+
+// MerkleProof lets a verifier, given a page's pre-step contents, recompute
+// the pre-step root and confirm the page was part of it.
+type MerkleProof struct {
+	PageIndex int
+	PageWords []uint16
+	Siblings  []Hash
+}
+
+// StepWitness is everything an external verifier needs to replay a single
+// instruction and check that it transforms PreStateRoot into
+// PostStateRoot: the fetched instruction, the register file before and
+// after, the processor state RTI/exception handling needs, a proof for
+// every memory word the step touched, and a proof for the register file
+// itself.
+type StepWitness struct {
+	PC             uint16
+	Instruction    uint16
+	RegsBefore     [RCount]uint16
+	RegsAfter      [RCount]uint16
+	RegsProof      []Hash
+	PSRBefore      uint16
+	SavedSSPBefore uint16
+	SavedUSPBefore uint16
+	MemAccesses    []MemAccess
+	PreStateRoot   Hash
+	PostStateRoot  Hash
+
+	// InterruptServiced is set when an asynchronous interrupt (Timer or
+	// an external RaiseInterrupt) was taken at the end of this step, on
+	// top of executing Instruction. This package's state hash doesn't
+	// cover device state (see StateHash), so Verify can't independently
+	// replay that half of the transition; when this is set it still checks
+	// Instruction's own effects but trusts the interrupt entry (see Verify).
+	InterruptServiced bool
+}
+
+// StepVM wraps a VM to produce a StepWitness per instruction, enabling
+// deterministic replay, snapshotting, and time-travel debugging as a side
+// effect of the same instrumentation an external fault-proof verifier would
+// consume.
+type StepVM struct {
+	VM *VM
+}
+
+// NewStepVM wraps an already-constructed VM for witnessed single-stepping.
+func NewStepVM(v *VM) *StepVM {
+	return &StepVM{VM: v}
+}
+
+// Step executes exactly one instruction and returns a witness for it. It
+// does not consult v.Running, so it can step through a HALT and beyond;
+// callers that care should check v.Running themselves between steps.
+func (s *StepVM) Step() (StepWitness, error) {
+	v := s.VM
+
+	preMem := snapshotMemory(v.Bus)
+	preRegs := v.Registers
+	prePSR := v.PSR
+	preSavedSSP := v.SavedSSP
+	preSavedUSP := v.SavedUSP
+	preLeaves := leaves(preMem, preRegs)
+	preRoot := merkleRoot(preLeaves)
+	regsProof := merkleProof(preLeaves, NumPages)
+
+	pc := v.Registers[RPc]
+
+	var touched []uint16
+	seen := map[uint16]bool{}
+	v.accessHook = func(address uint16, write bool) {
+		if !seen[address] {
+			seen[address] = true
+			touched = append(touched, address)
+		}
+	}
+	v.step()
+	interruptServiced := v.interruptServiced
+	v.accessHook = nil
+
+	postMem := snapshotMemory(v.Bus)
+	instruction := preMem[pc]
+
+	accesses := make([]MemAccess, 0, len(touched))
+	for _, addr := range touched {
+		pageIndex := int(addr) / PageWords
+		accesses = append(accesses, MemAccess{
+			Address: addr,
+			Before:  preMem[addr],
+			After:   postMem[addr],
+			Write:   preMem[addr] != postMem[addr],
+			Proof:   pageProof(preMem, preLeaves, pageIndex),
+		})
+	}
+
+	return StepWitness{
+		PC:                pc,
+		Instruction:       instruction,
+		RegsBefore:        preRegs,
+		RegsAfter:         v.Registers,
+		RegsProof:         regsProof,
+		PSRBefore:         prePSR,
+		SavedSSPBefore:    preSavedSSP,
+		SavedUSPBefore:    preSavedUSP,
+		MemAccesses:       accesses,
+		PreStateRoot:      preRoot,
+		PostStateRoot:     v.StateHash(),
+		InterruptServiced: interruptServiced,
+	}, nil
+}
+
+// pageProof builds the MerkleProof for the page containing a touched
+// address, against an already-computed set of pre-step leaves.
+func pageProof(mem [MemSize]uint16, ls []Hash, pageIndex int) MerkleProof {
+	start := pageIndex * PageWords
+	end := start + PageWords
+	if end > MemSize {
+		end = MemSize
+	}
+
+	words := make([]uint16, end-start)
+	copy(words, mem[start:end])
+
+	return MerkleProof{
+		PageIndex: pageIndex,
+		PageWords: words,
+		Siblings:  merkleProof(ls, pageIndex),
+	}
+}
+
+// Verify replays w against its own PreStateRoot. It checks RegsBefore and
+// every MemAccess.Before are Merkle-consistent with PreStateRoot, decodes
+// and replays Instruction against that proven pre-state to recompute
+// RegsAfter and every write's After value, and recombines the (possibly
+// changed) register leaf with every written page — via the same proofs —
+// to confirm the result really is PostStateRoot. A witness that lies
+// about any of those fails one of these checks; see replayState for the
+// replay semantics and combinedRoot for the post-root recombination.
+//
+// GETC/IN pull a character from an external keyboard the witness can't
+// reproduce, so their destination register is trusted from RegsAfter
+// rather than re-derived. If InterruptServiced is set, a Timer or
+// external RaiseInterrupt fired on top of Instruction; Instruction's own
+// register and memory effects are still checked, but the vector-driven
+// push onto the supervisor stack that follows is trusted from RegsAfter,
+// since this package's state hash doesn't cover the device state that
+// decides which vector fired (see StateHash).
+func (w *StepWitness) Verify() bool {
+	if !verifyMerkleProof(registersHash(w.RegsBefore), NumPages, w.RegsProof, w.PreStateRoot) {
+		return false
+	}
+
+	byAddr := make(map[uint16]MemAccess, len(w.MemAccesses))
+	pageProofs := map[int]MerkleProof{}
+	pageAfter := map[int][]uint16{}
+
+	for _, a := range w.MemAccesses {
+		if int(a.Address)/PageWords != a.Proof.PageIndex {
+			return false
+		}
+		offset := int(a.Address) - a.Proof.PageIndex*PageWords
+		if offset < 0 || offset >= len(a.Proof.PageWords) || a.Proof.PageWords[offset] != a.Before {
+			return false
+		}
+		if !verifyMerkleProof(pageHash(a.Proof.PageWords), a.Proof.PageIndex, a.Proof.Siblings, w.PreStateRoot) {
+			return false
+		}
+
+		byAddr[a.Address] = a
+		if _, ok := pageProofs[a.Proof.PageIndex]; !ok {
+			pageProofs[a.Proof.PageIndex] = a.Proof
+			words := make([]uint16, len(a.Proof.PageWords))
+			copy(words, a.Proof.PageWords)
+			pageAfter[a.Proof.PageIndex] = words
+		}
+		pageAfter[a.Proof.PageIndex][offset] = a.After
+	}
+
+	if a, ok := byAddr[w.PC]; !ok || a.Before != w.Instruction {
+		return false
+	}
+
+	rs := &replayState{
+		regs:     w.RegsBefore,
+		psr:      w.PSRBefore,
+		savedSSP: w.SavedSSPBefore,
+		savedUSP: w.SavedUSPBefore,
+		mem:      make(map[uint16]uint16, len(byAddr)),
+		writes:   map[uint16]uint16{},
+	}
+	for addr, a := range byAddr {
+		rs.mem[addr] = a.Before
+	}
+
+	rs.regs[RPc] = w.PC + 1
+
+	inst := decode(w.Instruction)
+	rs.execute(inst)
+	if rs.err != nil {
+		return false
+	}
+	if inst.Op == OpTrap && (inst.Args.TrapVec == TrapGetC || inst.Args.TrapVec == TrapIn) {
+		rs.regs[R0] = w.RegsAfter[R0]
+	}
+
+	if w.InterruptServiced {
+		// raiseException is the only thing that runs after Instruction,
+		// and it only ever touches R6 (switching to the supervisor
+		// stack), RPc (jumping through the vector), PSR, and the stack
+		// words it pushes; R6 and RPc are taken from RegsAfter. Every other
+		// register, and every non-interrupt memory write, is still
+		// exactly what Instruction itself produced, so check those;
+		// only the vector-driven push onto the supervisor stack is
+		// trusted unchecked.
+		for i := range rs.regs {
+			if i == int(RPc) || i == int(R6) {
+				continue
+			}
+			if rs.regs[i] != w.RegsAfter[i] {
+				return false
+			}
+		}
+		for addr, val := range rs.writes {
+			a, ok := byAddr[addr]
+			if !ok || a.After != val {
+				return false
+			}
+		}
+	} else {
+		if rs.regs != w.RegsAfter {
+			return false
+		}
+		for addr, val := range rs.writes {
+			a, ok := byAddr[addr]
+			if !ok || a.After != val {
+				return false
+			}
+		}
+		for addr, a := range byAddr {
+			if a.Write {
+				if _, ok := rs.writes[addr]; !ok {
+					return false
+				}
+			}
+		}
+	}
+
+	depth := merkleDepth(NumPages + 1)
+	updates := map[int]Hash{NumPages: registersHash(w.RegsAfter)}
+	siblings := map[int][]Hash{NumPages: w.RegsProof}
+	for pageIndex, words := range pageAfter {
+		updates[pageIndex] = pageHash(words)
+		siblings[pageIndex] = pageProofs[pageIndex].Siblings
+	}
+
+	return combinedRoot(updates, siblings, depth) == w.PostStateRoot
+}
@@ -0,0 +1,102 @@
+package vm
+
+import "testing"
+
+// testIO is a minimal IO double: no key is ever ready, GetChar is never
+// called in these tests, and Out just appends to a buffer.
+type testIO struct {
+	out []byte
+}
+
+func (t *testIO) CheckKey() bool  { return false }
+func (t *testIO) GetChar() uint16 { return 0 }
+func (t *testIO) Out(c byte)      { t.out = append(t.out, c) }
+
+func newTestVM() *VM {
+	return New(NewSystemBus(), &testIO{})
+}
+
+func TestStepVMVerifyRoundTrip(t *testing.T) {
+	v := newTestVM()
+	v.Bus.Write(PcStart, OpAdd<<12|R0<<9|R0<<6|1<<5|0x1f) // ADD R0,R0,#-1
+	v.Registers[R0] = 5
+
+	w, err := NewStepVM(v).Step()
+	if err != nil {
+		t.Fatalf("Step: %v", err)
+	}
+	if v.Registers[R0] != 4 {
+		t.Fatalf("R0 = %d, want 4", v.Registers[R0])
+	}
+	if !w.Verify() {
+		t.Fatal("Verify() = false for a genuine, untampered witness")
+	}
+}
+
+func TestStepVMVerifyDetectsTamperedRegister(t *testing.T) {
+	v := newTestVM()
+	v.Bus.Write(PcStart, OpAdd<<12|R0<<9|R0<<6|1<<5|0x1f) // ADD R0,R0,#-1
+	v.Registers[R0] = 5
+
+	w, err := NewStepVM(v).Step()
+	if err != nil {
+		t.Fatalf("Step: %v", err)
+	}
+
+	w.RegsAfter[R0]++
+	if w.Verify() {
+		t.Fatal("Verify() = true for a witness with a tampered RegsAfter")
+	}
+}
+
+func TestStepVMVerifyDetectsTamperedMemWrite(t *testing.T) {
+	v := newTestVM()
+	v.Bus.Write(PcStart, OpStore<<12|R0<<9|1) // ST R0, #1 -> writes PcStart+2
+	v.Registers[R0] = 0x1234
+
+	w, err := NewStepVM(v).Step()
+	if err != nil {
+		t.Fatalf("Step: %v", err)
+	}
+	if got := v.Bus.Read(PcStart + 2); got != 0x1234 {
+		t.Fatalf("Bus.Read(PcStart+2) = %#04x, want 0x1234", got)
+	}
+
+	for i := range w.MemAccesses {
+		if w.MemAccesses[i].Address == PcStart+2 {
+			w.MemAccesses[i].After++
+		}
+	}
+	if w.Verify() {
+		t.Fatal("Verify() = true for a witness with a tampered memory write")
+	}
+}
+
+func TestStepVMVerifyInterruptServiced(t *testing.T) {
+	v := newTestVM()
+	v.Bus.Write(PcStart, OpAdd<<12|R0<<9|R0<<6|1<<5|0x1f) // ADD R0,R0,#-1
+	v.Registers[R0] = 5
+	v.RaiseInterrupt(VectorPrivilegeViolation)
+	v.Bus.Write(VectorPrivilegeViolation, 0x1000) // handler entry point
+
+	w, err := NewStepVM(v).Step()
+	if err != nil {
+		t.Fatalf("Step: %v", err)
+	}
+	if !w.InterruptServiced {
+		t.Fatal("InterruptServiced = false, want true")
+	}
+	if !w.Verify() {
+		t.Fatal("Verify() = false for a genuine interrupt-serviced witness")
+	}
+
+	// Instruction's own effect (R0) is still checked even though an
+	// interrupt fired afterward: forging it, and recomputing a
+	// consistent PostStateRoot to match, must still fail.
+	forged := w
+	forged.RegsAfter[R0]++
+	forged.PostStateRoot = merkleRoot(leaves(snapshotMemory(v.Bus), forged.RegsAfter))
+	if forged.Verify() {
+		t.Fatal("Verify() = true for an interrupt-serviced witness with a forged instruction effect")
+	}
+}
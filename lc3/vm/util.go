@@ -0,0 +1,21 @@
+package vm
+
+func signExtend(num uint16, bitCount uint) uint16 {
+
+	if (num>>(bitCount-1))&1 == 1 {
+		return num | (0xffff << bitCount)
+	}
+
+	return num
+}
+
+func (v *VM) updateFlags(register uint16) {
+
+	if v.Registers[register] == 0 {
+		v.Registers[RCond] = FlagZero
+	} else if v.Registers[register]>>15 == 1 {
+		v.Registers[RCond] = FlagNegative
+	} else {
+		v.Registers[RCond] = FlagPositive
+	}
+}
@@ -0,0 +1,211 @@
+// Package vm implements the LC-3 CPU: memory, registers, and the
+// fetch/decode/execute loop. It has no knowledge of terminals, files, or
+// assemblers — those live in sibling packages (lc3/asm, lc3/disasm) and in
+// the IO interface below, so the VM can be embedded in other programs.
+package vm
+
+import "bufio"
+
+// MemSize is the size of the LC-3 address space in 16-bit words: 2^16
+// addresses, x0000 through xFFFF inclusive.
+const MemSize = 65536
+
+// Register indices into the VM's register file.
+const (
+	R0 = iota
+	R1
+	R2
+	R3
+	R4
+	R5
+	R6
+	R7
+	RPc
+	RCond
+	RCount
+)
+
+// Opcodes, in the order LC-3 assigns them.
+const (
+	OpBreak = iota
+	OpAdd
+	OpLoad
+	OpStore
+	OpJumpRegister
+	OpAnd
+	OpLoadRegister
+	OpStoreRegister
+	OpRti
+	OpNot
+	OpLoadIndirect
+	OpStoreIndirect
+	OpJump
+	OpRes
+	OpLoadEffectiveAddress
+	OpTrap
+)
+
+// Trap vectors.
+const (
+	TrapGetC  = 0x20 /* get character from keyboard */
+	TrapOut   = 0x21 /* output a character */
+	TrapPutS  = 0x22 /* output a word string */
+	TrapIn    = 0x23 /* prompt for and read a character */
+	TrapPutSP = 0x24 /* output a byte string */
+	TrapHalt  = 0x25 /* halt the program */
+)
+
+// Condition flags.
+const (
+	FlagPositive = 1 << 0
+	FlagZero     = 1 << 1
+	FlagNegative = 1 << 2
+)
+
+// PcStart is the address execution begins at once an image is loaded.
+const PcStart = 0x3000
+
+// PSR (processor status register) bits. The base LC-3 ISA only specifies
+// bit 15 (privilege mode); condition codes are tracked separately in
+// RCond for compatibility with the rest of this package.
+const (
+	PsrUserMode = 1 << 15
+)
+
+// Exception vectors serviced by raiseException. The base LC-3 ISA assigns
+// x00 to a privilege-mode violation; it leaves "illegal opcode" undefined,
+// so this package reserves x01 for OpRes and documents the choice here
+// rather than inventing it silently at the call site.
+const (
+	VectorPrivilegeViolation = 0x00
+	VectorIllegalOpcode      = 0x01
+)
+
+// conventional supervisor-stack top, matching the reference lc3tools
+// simulator so object files that never touch R6 still behave sensibly if
+// they trap into an exception handler.
+const initialSSP = 0x3000
+
+// IO decouples the VM from any particular terminal. Implementations supply
+// keyboard polling/blocking reads and character output; cmd/lc3 backs this
+// with curses, but embedders are free to wire up anything else (a pipe, a
+// test double, a headless buffer).
+type IO interface {
+	// CheckKey reports whether a keypress is available without blocking.
+	CheckKey() bool
+	// GetChar blocks until a character is available and returns it.
+	GetChar() uint16
+	// Out writes a single character of program output.
+	Out(c byte)
+}
+
+// VM holds the full architectural state of an LC-3 machine. Memory lives
+// behind Bus rather than in a fixed array here, so it can be RAM alone, RAM
+// plus MMIO peripherals, or something a caller supplies entirely.
+type VM struct {
+	Bus       Bus
+	Registers [RCount]uint16
+	Running   bool
+
+	// PSR, SavedSSP, and SavedUSP back the privilege-mode handling used by
+	// RTI and exception entry; see raiseException.
+	PSR      uint16
+	SavedSSP uint16
+	SavedUSP uint16
+
+	IO IO
+
+	// Timer, if non-nil, is ticked once per executed instruction; see
+	// devices.go. It is nil unless NewDefault (or a caller) installs one.
+	Timer *Timer
+
+	// pendingInterrupt holds a vector raised by RaiseInterrupt until the
+	// next instruction boundary, matching real LC-3 interrupt timing.
+	pendingInterrupt *uint16
+
+	// interruptServiced records whether the most recent step serviced a
+	// pending interrupt (from Timer or RaiseInterrupt) on top of
+	// executing its instruction. StepVM surfaces it in the witness,
+	// since that transition isn't derivable from the instruction alone.
+	interruptServiced bool
+
+	// accessHook, when set, is notified of every memory access (read or
+	// write) made during execution. StepVM uses it to record the addresses
+	// touched by a single step for its witness; it is nil otherwise.
+	accessHook func(address uint16, write bool)
+
+	// Breakpoints and watchpoints back lc3/debug's GDB stub (and any other
+	// embedder); see debughooks.go.
+	Breakpoints map[uint16]struct{}
+	watchpoints []watchpoint
+	nextWatchID int
+
+	// Symbols is the label -> address table from the assembled program, if
+	// any; it rides along in a snapshot so lc3/disasm can annotate a
+	// listing taken from a restored VM. See snapshot.go.
+	Symbols map[string]uint16
+}
+
+// New returns a VM wired to bus, with its program counter set to the
+// conventional LC-3 load address, running in user mode. Callers still need
+// to load an image before calling Run.
+func New(bus Bus, io IO) *VM {
+	v := &VM{Bus: bus, IO: io}
+	v.Registers[RPc] = PcStart
+	v.PSR = PsrUserMode
+	v.SavedSSP = initialSSP
+	return v
+}
+
+// NewDefault assembles the built-in bus — RAM plus a keyboard, display,
+// MCR, and timer — and returns a VM wired to it. This is what cmd/lc3 uses;
+// embedders who want a different device mix should build their own Bus and
+// call New directly.
+func NewDefault(io IO) *VM {
+	bus := NewSystemBus()
+	v := New(bus, io)
+
+	bus.Attach(NewKeyboard(io))
+	bus.Attach(NewDisplay(io))
+	bus.Attach(NewMCR(func() { v.Running = false }))
+
+	v.Timer = NewTimer(func() { v.RaiseInterrupt(VectorTimer) })
+	bus.Attach(v.Timer)
+
+	return v
+}
+
+// RaiseInterrupt records vector to be serviced at the next instruction
+// boundary. It's how devices like Timer signal the VM without reaching
+// into its execution loop directly.
+func (v *VM) RaiseInterrupt(vector uint16) {
+	vec := vector
+	v.pendingInterrupt = &vec
+}
+
+// StdIO is a minimal IO implementation suitable for non-interactive use: it
+// blocks on Input for GetChar, never reports a pending key, and writes
+// output to Output. Interactive front ends (see cmd/lc3) will typically
+// supply their own IO backed by a terminal library instead.
+type StdIO struct {
+	Input  *bufio.Reader
+	Output interface {
+		Write(p []byte) (int, error)
+	}
+}
+
+func (s *StdIO) CheckKey() bool {
+	return false
+}
+
+func (s *StdIO) GetChar() uint16 {
+	char, _, err := s.Input.ReadRune()
+	if err != nil {
+		panic(err)
+	}
+	return uint16(byte(char))
+}
+
+func (s *StdIO) Out(c byte) {
+	s.Output.Write([]byte{c})
+}